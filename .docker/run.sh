@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+set -e
 
 ./cmdline --migrate --seed
-./main
\ No newline at end of file
+exec ./main
\ No newline at end of file
@@ -0,0 +1,158 @@
+package challenges
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+)
+
+var catalog []Definition
+
+func InitializeCatalog() error {
+	dir := os.Getenv("CHALLENGES_DIR")
+	if dir == "" {
+		dir = "./challenges/definitions"
+	}
+
+	definitions, err := LoadDefinitions(dir)
+	if err != nil {
+		return err
+	}
+	catalog = definitions
+
+	log.Printf("Loaded %d challenge definitions from %s", len(catalog), dir)
+	return nil
+}
+
+func Catalog() []Definition {
+	return catalog
+}
+
+// FlagNames returns the challenges whose flag the queries of codePath may
+// read.
+func FlagNames(codePath string) []string {
+	var names []string
+	for _, definition := range catalog {
+		if definition.CodePath == codePath && definition.HasFlag() {
+			names = append(names, definition.ID)
+		}
+	}
+	return names
+}
+
+// Sync makes the challenges and flags tables match the catalog, the only
+// source of truth for both. Challenges are upserted, and those that are no
+// longer defined, or that need participant schemas on other dialects, are
+// deleted along with their hints and solves. Hints are only copied from a
+// definition when its challenge is first synced. After that they belong to
+// the admin hint endpoints, so edits made there survive a restart.
+// Generated flags are only created once per deployment.
+func Sync(exec db.Executor) error {
+	defined := map[string]bool{}
+	flagged := map[string]bool{}
+	for _, definition := range catalog {
+		if definition.RequiresSchemas && !db.SupportsSchemas() {
+			continue
+		}
+		defined[definition.ID] = true
+		if definition.HasFlag() {
+			flagged[definition.ID] = true
+		}
+
+		var exists bool
+		err := exec.QueryRow("SELECT EXISTS (SELECT 1 FROM challenges WHERE id = $1)", definition.ID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to sync challenge %s: %v", definition.ID, err)
+		}
+
+		query := `INSERT INTO challenges (id, title, description, category, points, endpoint)
+			VALUES ($1, $2, $3, $4, $5, $6)` + db.OnConflictUpdate([]string{"id"}, "title", "description", "category", "points", "endpoint", "updated_at")
+		_, err = exec.Exec(query, definition.ID, definition.Title, definition.Description, definition.Category, definition.Points, definition.Endpoint)
+		if err != nil {
+			return fmt.Errorf("failed to sync challenge %s: %v", definition.ID, err)
+		}
+
+		if !exists {
+			for i, hint := range definition.Hints {
+				hintQuery := "INSERT INTO hints (challenge_id, position, content, cost) VALUES ($1, $2, $3, $4)"
+				_, err := exec.Exec(hintQuery, definition.ID, i+1, hint.Content, hint.Cost)
+				if err != nil {
+					return fmt.Errorf("failed to sync hint %d of challenge %s: %v", i+1, definition.ID, err)
+				}
+			}
+		}
+
+		switch {
+		case definition.Flag != "":
+			flagQuery := "INSERT INTO flags (name, value) VALUES ($1, $2)" + db.OnConflictUpdate([]string{"name"}, "value")
+			_, err = exec.Exec(flagQuery, definition.ID, definition.Flag)
+		case definition.FlagGenerator == FlagGeneratorRandom:
+			flagQuery := "INSERT INTO flags (name, value) VALUES ($1, $2)" + db.OnConflictDoNothing("name")
+			_, err = exec.Exec(flagQuery, definition.ID, generateFlag())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to sync flag of challenge %s: %v", definition.ID, err)
+		}
+	}
+
+	removed, err := prune(exec, "SELECT id FROM challenges", "DELETE FROM challenges WHERE id = $1", defined)
+	if err != nil {
+		return fmt.Errorf("failed to remove challenges: %v", err)
+	}
+	for _, id := range removed {
+		log.Printf("Removed challenge %s, which is not in the catalog", id)
+	}
+
+	if _, err := prune(exec, "SELECT name FROM flags", "DELETE FROM flags WHERE name = $1", flagged); err != nil {
+		return fmt.Errorf("failed to remove flags: %v", err)
+	}
+
+	log.Printf("Synced %d challenges", len(defined))
+	return nil
+}
+
+// prune runs deleteQuery for every key listed by query that is not in keep,
+// and returns the keys it deleted.
+func prune(exec db.Executor, query, deleteQuery string, keep map[string]bool) ([]string, error) {
+	rows, err := exec.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stale []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if !keep[key] {
+			stale = append(stale, key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, key := range stale {
+		if _, err := exec.Exec(deleteQuery, key); err != nil {
+			return nil, err
+		}
+	}
+	return stale, nil
+}
+
+func generateFlag() string {
+	var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	b := make([]rune, 32)
+	for i := range b {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		b[i] = letters[n.Int64()]
+	}
+	return "FLAG{" + string(b) + "}"
+}
@@ -0,0 +1,152 @@
+package challenges
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FlagGeneratorRandom = "random"
+	// FlagGeneratorNone is for challenges the lab credits itself, e.g. by
+	// detecting the result of an exploit, so there is no flag to leak.
+	FlagGeneratorNone = "none"
+)
+
+type HintDefinition struct {
+	Content string `json:"content" yaml:"content"`
+	Cost    int    `json:"cost" yaml:"cost"`
+}
+
+type Definition struct {
+	ID            string `json:"id" yaml:"id"`
+	Title         string `json:"title" yaml:"title"`
+	Description   string `json:"description" yaml:"description"`
+	Category      string `json:"category" yaml:"category"`
+	Points        int    `json:"points" yaml:"points"`
+	Flag          string `json:"flag" yaml:"flag"`
+	FlagGenerator string `json:"flag_generator" yaml:"flag_generator"`
+	Endpoint      string `json:"endpoint" yaml:"endpoint"`
+	// CodePath is the lab code path whose vulnerable query can read the
+	// flag. No other query gets to see it.
+	CodePath string `json:"code_path" yaml:"code_path"`
+	// RequiresSchemas leaves the challenge out on dialects where every
+	// participant shares one database, since solving it there would break
+	// the lab for everyone.
+	RequiresSchemas bool             `json:"requires_schemas" yaml:"requires_schemas"`
+	Hints           []HintDefinition `json:"hints" yaml:"hints"`
+
+	File string `json:"-" yaml:"-"`
+}
+
+var idPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// HasFlag reports whether the challenge is solved by submitting a flag.
+func (d Definition) HasFlag() bool {
+	return d.Flag != "" || d.FlagGenerator == FlagGeneratorRandom
+}
+
+func (d Definition) Validate() error {
+	var problems []string
+
+	if !idPattern.MatchString(d.ID) || len(d.ID) > 100 {
+		problems = append(problems, "id must be a lowercase slug of at most 100 characters")
+	}
+	if strings.TrimSpace(d.Title) == "" {
+		problems = append(problems, "title is required")
+	}
+	if strings.TrimSpace(d.Category) == "" {
+		problems = append(problems, "category is required")
+	}
+	if d.Points <= 0 {
+		problems = append(problems, "points must be positive")
+	}
+	if (d.Flag == "") == (d.FlagGenerator == "") {
+		problems = append(problems, "exactly one of flag or flag_generator must be set")
+	}
+	if d.FlagGenerator != "" && d.FlagGenerator != FlagGeneratorRandom && d.FlagGenerator != FlagGeneratorNone {
+		problems = append(problems, fmt.Sprintf("unknown flag_generator %q", d.FlagGenerator))
+	}
+	if d.CodePath == "" && d.HasFlag() {
+		problems = append(problems, "code_path is required for a challenge with a flag")
+	}
+	if d.CodePath != "" && !lab.IsCodePath(d.CodePath) {
+		problems = append(problems, fmt.Sprintf("unknown code_path %q", d.CodePath))
+	}
+	for i, hint := range d.Hints {
+		if strings.TrimSpace(hint.Content) == "" {
+			problems = append(problems, fmt.Sprintf("hint %d has no content", i+1))
+		}
+		if hint.Cost < 0 {
+			problems = append(problems, fmt.Sprintf("hint %d has a negative cost", i+1))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func LoadDefinitions(dir string) ([]Definition, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read challenges directory: %v", err)
+	}
+
+	var definitions []Definition
+	seen := make(map[string]string)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		var definition Definition
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json":
+			err = decodeFile(path, func(data []byte) error { return json.Unmarshal(data, &definition) })
+		case ".yaml", ".yml":
+			err = decodeFile(path, func(data []byte) error { return yaml.Unmarshal(data, &definition) })
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		definition.File = path
+		if err := definition.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid challenge definition %s: %v", path, err)
+		}
+		if other, exists := seen[definition.ID]; exists {
+			return nil, fmt.Errorf("challenge %s is defined in both %s and %s", definition.ID, other, path)
+		}
+		seen[definition.ID] = path
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].ID < definitions[j].ID
+	})
+	return definitions, nil
+}
+
+func decodeFile(path string, decode func(data []byte) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read challenge definition %s: %v", path, err)
+	}
+	if err := decode(data); err != nil {
+		return fmt.Errorf("failed to parse challenge definition %s: %v", path, err)
+	}
+	return nil
+}
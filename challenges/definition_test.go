@@ -0,0 +1,197 @@
+package challenges
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/noverdy/sqli-demo-lab/lab"
+)
+
+func validDefinition() Definition {
+	return Definition{
+		ID:            "blind-sqli",
+		Title:         "Blind Purchase",
+		Category:      "blind",
+		Points:        500,
+		FlagGenerator: FlagGeneratorRandom,
+		Endpoint:      "POST /api/internet-packages/buy",
+		CodePath:      lab.PathBuyInternetPackage,
+		Hints:         []HintDefinition{{Content: "Try a single quote.", Cost: 50}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(d *Definition)
+		// problems are the messages Validate must report, none for a valid
+		// definition.
+		problems []string
+	}{
+		{
+			name:   "valid",
+			modify: func(d *Definition) {},
+		},
+		{
+			name:   "fixed flag",
+			modify: func(d *Definition) { d.FlagGenerator, d.Flag = "", "FLAG{fixed}" },
+		},
+		{
+			name:   "no flag without code path",
+			modify: func(d *Definition) { d.FlagGenerator, d.CodePath = FlagGeneratorNone, "" },
+		},
+		{
+			name:   "free hint",
+			modify: func(d *Definition) { d.Hints[0].Cost = 0 },
+		},
+		{
+			name:     "uppercase id",
+			modify:   func(d *Definition) { d.ID = "Blind-SQLi" },
+			problems: []string{"id must be a lowercase slug"},
+		},
+		{
+			name:     "id with trailing dash",
+			modify:   func(d *Definition) { d.ID = "blind-" },
+			problems: []string{"id must be a lowercase slug"},
+		},
+		{
+			name:     "id too long",
+			modify:   func(d *Definition) { d.ID = strings.Repeat("a", 101) },
+			problems: []string{"id must be a lowercase slug"},
+		},
+		{
+			name:     "blank title and category",
+			modify:   func(d *Definition) { d.Title, d.Category = " ", "" },
+			problems: []string{"title is required", "category is required"},
+		},
+		{
+			name:     "no points",
+			modify:   func(d *Definition) { d.Points = 0 },
+			problems: []string{"points must be positive"},
+		},
+		{
+			name:     "flag and generator",
+			modify:   func(d *Definition) { d.Flag = "FLAG{fixed}" },
+			problems: []string{"exactly one of flag or flag_generator must be set"},
+		},
+		{
+			name:     "neither flag nor generator",
+			modify:   func(d *Definition) { d.FlagGenerator = "" },
+			problems: []string{"exactly one of flag or flag_generator must be set"},
+		},
+		{
+			name:     "unknown generator",
+			modify:   func(d *Definition) { d.FlagGenerator = "sequential" },
+			problems: []string{`unknown flag_generator "sequential"`},
+		},
+		{
+			name:     "flag without code path",
+			modify:   func(d *Definition) { d.CodePath = "" },
+			problems: []string{"code_path is required for a challenge with a flag"},
+		},
+		{
+			name:     "unknown code path",
+			modify:   func(d *Definition) { d.CodePath = "delete-everything" },
+			problems: []string{`unknown code_path "delete-everything"`},
+		},
+		{
+			name: "bad hints",
+			modify: func(d *Definition) {
+				d.Hints = append(d.Hints, HintDefinition{Content: "\n"}, HintDefinition{Content: "ok", Cost: -1})
+			},
+			problems: []string{"hint 2 has no content", "hint 3 has a negative cost"},
+		},
+		{
+			name: "every problem at once",
+			modify: func(d *Definition) {
+				*d = Definition{Hints: []HintDefinition{{}}}
+			},
+			problems: []string{
+				"id must be a lowercase slug",
+				"title is required",
+				"category is required",
+				"points must be positive",
+				"exactly one of flag or flag_generator must be set",
+				"hint 1 has no content",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition := validDefinition()
+			tt.modify(&definition)
+
+			err := definition.Validate()
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %q", tt.problems)
+			}
+
+			got := strings.Split(err.Error(), "; ")
+			if len(got) != len(tt.problems) {
+				t.Fatalf("Validate() = %q, want %d problems %q", err, len(tt.problems), tt.problems)
+			}
+			for i, problem := range tt.problems {
+				if !strings.HasPrefix(got[i], problem) {
+					t.Errorf("problem %d = %q, want %q", i+1, got[i], problem)
+				}
+			}
+		})
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	tests := []struct {
+		definition Definition
+		want       bool
+	}{
+		{Definition{Flag: "FLAG{fixed}"}, true},
+		{Definition{FlagGenerator: FlagGeneratorRandom}, true},
+		{Definition{FlagGenerator: FlagGeneratorNone}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.definition.HasFlag(); got != tt.want {
+			t.Errorf("%+v.HasFlag() = %t, want %t", tt.definition, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDefinitionsShipped(t *testing.T) {
+	definitions, err := LoadDefinitions("definitions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(definitions) == 0 {
+		t.Fatal("no challenge definitions found")
+	}
+	for i := 1; i < len(definitions); i++ {
+		if definitions[i-1].ID >= definitions[i].ID {
+			t.Errorf("definitions are not sorted by id: %s before %s", definitions[i-1].ID, definitions[i].ID)
+		}
+	}
+}
+
+func TestLoadDefinitionsDuplicateID(t *testing.T) {
+	dir := t.TempDir()
+	yamlDefinition := "id: dup\ntitle: A\ncategory: c\npoints: 1\nflag_generator: none\n"
+	jsonDefinition := `{"id": "dup", "title": "B", "category": "c", "points": 1, "flag_generator": "none"}`
+	for name, content := range map[string]string{"a.yaml": yamlDefinition, "b.json": jsonDefinition, "notes.txt": "ignored"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := LoadDefinitions(dir)
+	if err == nil || !strings.Contains(err.Error(), "challenge dup is defined in both") {
+		t.Fatalf("LoadDefinitions() = %v, want a duplicate id error", err)
+	}
+}
@@ -7,8 +7,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/noverdy/sqli-demo-lab/challenges"
 	"github.com/noverdy/sqli-demo-lab/db"
 	"github.com/noverdy/sqli-demo-lab/seeders"
+	"github.com/noverdy/sqli-demo-lab/services"
 )
 
 func main() {
@@ -23,31 +25,58 @@ func main() {
 	migrate := flag.Bool("migrate", false, "Apply database migrations")
 	rollback := flag.Bool("rollback", false, "Rollback database migrations")
 	seed := flag.Bool("seed", false, "Run database seeders")
+	reset := flag.Bool("reset", false, "Drop everything, then re-apply migrations and seeders")
 	server := flag.Bool("server", false, "Start the server")
 	flag.Parse()
 
 	if *migrate {
 		log.Println("Applying migrations...")
-		err := db.ApplyMigrations(db.DB, "./migrations")
+		err := db.ApplyMigrations(db.DB, db.MigrationsDir())
 		if err != nil {
 			log.Fatalf("Failed to apply migrations: %v", err)
 		}
+		err = services.MigrateParticipantSchemas()
+		if err != nil {
+			log.Fatalf("Failed to migrate participant schemas: %v", err)
+		}
 		log.Println("Migrations applied successfully!")
 	}
 
 	if *rollback {
 		log.Println("Rolling back migrations...")
-		err := db.RollbackMigrations(db.DB, "./migrations")
+		err := db.RollbackMigrations(db.DB, db.MigrationsDir())
 		if err != nil {
 			log.Fatalf("Failed to rollback migrations: %v", err)
 		}
 		log.Println("Migrations rolled back successfully!")
 	}
 
+	if *reset {
+		if err := challenges.InitializeCatalog(); err != nil {
+			log.Fatalf("Failed to load challenges: %v", err)
+		}
+
+		log.Println("Resetting the lab...")
+		adminPassword, err := services.ResetLab()
+		if err != nil {
+			log.Fatalf("Failed to reset the lab: %v", err)
+		}
+		log.Printf("New admin password: %s", adminPassword)
+		log.Println("Lab reset successfully!")
+	}
+
 	if *seed {
 		log.Println("Running seeders...")
-		seeders.SeedUsers()
-		seeders.SeedInternetPackages()
+		if adminPassword := seeders.SeedUsers(db.DB); adminPassword != "" {
+			log.Printf("Admin password: %s", adminPassword)
+		}
+		seeders.SeedInternetPackages(db.DB)
+		if err := challenges.InitializeCatalog(); err != nil {
+			log.Fatalf("Failed to load challenges: %v", err)
+		}
+		if err := challenges.Sync(db.DB); err != nil {
+			log.Fatalf("Failed to sync challenges: %v", err)
+		}
 		log.Println("Seeders completed successfully!")
 	}
 
@@ -56,8 +85,8 @@ func main() {
 		startServer()
 	}
 
-	if !*migrate && !*rollback && !*seed && !*server {
-		log.Println("No valid command provided. Use --migrate, --rollback, --seed, or --server.")
+	if !*migrate && !*rollback && !*reset && !*seed && !*server {
+		log.Println("No valid command provided. Use --migrate, --rollback, --reset, --seed, or --server.")
 	}
 }
 
@@ -0,0 +1,102 @@
+package collaborator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+type Config struct {
+	HTTPPort int
+	DNSPort  int
+	Domain   string
+	// Host is the address the lab database uses to reach the listeners.
+	Host string
+}
+
+type Recorder func(hit models.CollaboratorHit)
+
+var config Config
+
+func InitializeConfig() error {
+	config = Config{
+		Domain: strings.ToLower(strings.Trim(os.Getenv("COLLABORATOR_DOMAIN"), ".")),
+		Host:   os.Getenv("COLLABORATOR_HOST"),
+	}
+	if config.Domain == "" {
+		config.Domain = "oob.lab"
+	}
+	if config.Host == "" {
+		config.Host = "localhost"
+	}
+
+	var err error
+	if config.HTTPPort, err = parsePort("COLLABORATOR_HTTP_PORT"); err != nil {
+		return err
+	}
+	if config.DNSPort, err = parsePort("COLLABORATOR_DNS_PORT"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parsePort(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s must be a port number, got %q", name, value)
+	}
+	return port, nil
+}
+
+func CurrentConfig() Config {
+	return config
+}
+
+func (c Config) Enabled() bool {
+	return c.HTTPPort != 0 || c.DNSPort != 0
+}
+
+// Start launches the enabled listeners in the background. Every request they
+// receive is handed to record.
+func Start(record Recorder) error {
+	if config.HTTPPort != 0 {
+		if err := startHTTP(config, record); err != nil {
+			return err
+		}
+		log.Printf("Collaborator HTTP listener is running on port %d", config.HTTPPort)
+	}
+
+	if config.DNSPort != 0 {
+		if err := startDNS(config, record); err != nil {
+			return err
+		}
+		log.Printf("Collaborator DNS listener is running on port %d for *.%s", config.DNSPort, config.Domain)
+	}
+
+	return nil
+}
+
+// parseHostname splits "<data>.<token>.<domain>" into its token and data.
+// The data may itself contain dots.
+func parseHostname(name string, domain string) (string, string, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	prefix, found := strings.CutSuffix(name, "."+domain)
+	if !found || prefix == "" {
+		return "", "", false
+	}
+
+	index := strings.LastIndex(prefix, ".")
+	if index == -1 {
+		return prefix, "", true
+	}
+	return prefix[index+1:], prefix[:index], true
+}
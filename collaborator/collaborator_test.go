@@ -0,0 +1,37 @@
+package collaborator
+
+import "testing"
+
+func TestParseHostname(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		domain string
+		token  string
+		data   string
+		ok     bool
+	}{
+		{"token only", "abc123.oob.lab", "oob.lab", "abc123", "", true},
+		{"token and data", "secret.abc123.oob.lab", "oob.lab", "abc123", "secret", true},
+		{"dotted data", "a.b.c.abc123.oob.lab", "oob.lab", "abc123", "a.b.c", true},
+		{"trailing dot", "secret.abc123.oob.lab.", "oob.lab", "abc123", "secret", true},
+		{"mixed case", "SeCrEt.ABC123.OOB.Lab", "oob.lab", "abc123", "secret", true},
+		{"empty data label", ".abc123.oob.lab", "oob.lab", "abc123", "", true},
+		{"domain only", "oob.lab", "oob.lab", "", "", false},
+		{"empty prefix", ".oob.lab", "oob.lab", "", "", false},
+		{"other domain", "secret.abc123.example.com", "oob.lab", "", "", false},
+		{"domain as suffix of a label", "abc123.foooob.lab", "oob.lab", "", "", false},
+		{"domain in the middle", "abc123.oob.lab.example.com", "oob.lab", "", "", false},
+		{"ip address", "127.0.0.1", "oob.lab", "", "", false},
+		{"empty", "", "oob.lab", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, data, ok := parseHostname(tt.host, tt.domain)
+			if token != tt.token || data != tt.data || ok != tt.ok {
+				t.Errorf("parseHostname(%q, %q) = %q, %q, %t, want %q, %q, %t", tt.host, tt.domain, token, data, ok, tt.token, tt.data, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package collaborator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/noverdy/sqli-demo-lab/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func startDNS(config Config, record Recorder) error {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", config.DNSPort))
+	if err != nil {
+		return fmt.Errorf("failed to start collaborator DNS listener: %v", err)
+	}
+
+	go serveDNS(conn, config, record)
+	return nil
+}
+
+func serveDNS(conn net.PacketConn, config Config, record Recorder) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+
+		var parser dnsmessage.Parser
+		header, err := parser.Start(buf[:n])
+		if err != nil {
+			continue
+		}
+		question, err := parser.Question()
+		if err != nil {
+			continue
+		}
+
+		name := question.Name.String()
+		token, data, ok := parseHostname(name, config.Domain)
+		if ok {
+			sourceIP, _, err := net.SplitHostPort(addr.String())
+			if err != nil {
+				sourceIP = addr.String()
+			}
+			record(models.CollaboratorHit{
+				Token:    token,
+				Protocol: "dns",
+				Data:     data,
+				SourceIP: sourceIP,
+				Raw:      fmt.Sprintf("%s %s", question.Type, name),
+			})
+		}
+
+		reply, err := buildDNSReply(header, question, ok)
+		if err != nil {
+			continue
+		}
+		conn.WriteTo(reply, addr)
+	}
+}
+
+// buildDNSReply answers A queries for our domain with 127.0.0.1 so that the
+// client does not keep retrying, and everything else with NXDOMAIN.
+func buildDNSReply(header dnsmessage.Header, question dnsmessage.Question, known bool) ([]byte, error) {
+	replyHeader := dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: false,
+		RCode:              dnsmessage.RCodeSuccess,
+	}
+	if !known {
+		replyHeader.RCode = dnsmessage.RCodeNameError
+	}
+
+	builder := dnsmessage.NewBuilder(nil, replyHeader)
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+
+	if known && question.Type == dnsmessage.TypeA {
+		if err := builder.StartAnswers(); err != nil {
+			return nil, err
+		}
+		resource := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 0}
+		if err := builder.AResource(resource, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Finish()
+}
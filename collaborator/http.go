@@ -0,0 +1,83 @@
+package collaborator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+const (
+	// maxBodySize caps the request body that is read for the raw dump, and
+	// maxRawSize the dump that is stored with the hit.
+	maxBodySize = 64 << 10
+	maxRawSize  = 16 << 10
+)
+
+func startHTTP(config Config, record Recorder) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.HTTPPort))
+	if err != nil {
+		return fmt.Errorf("failed to start collaborator HTTP listener: %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		token, data, ok := parseHostname(host, config.Domain)
+		if !ok {
+			path := strings.TrimPrefix(r.URL.Path, "/")
+			token, data, _ = strings.Cut(path, "/")
+			if r.URL.RawQuery != "" {
+				data += "?" + r.URL.RawQuery
+			}
+		}
+
+		sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			sourceIP = r.RemoteAddr
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		raw, err := httputil.DumpRequest(r, true)
+		if err != nil {
+			raw, _ = httputil.DumpRequest(r, false)
+		}
+		if len(raw) > maxRawSize {
+			raw = raw[:maxRawSize]
+		}
+
+		record(models.CollaboratorHit{
+			Token:    token,
+			Protocol: "http",
+			Data:     data,
+			SourceIP: sourceIP,
+			Raw:      string(raw),
+		})
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok\n"))
+	})
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		MaxHeaderBytes:    maxRawSize,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Collaborator HTTP listener stopped: %v", err)
+		}
+	}()
+	return nil
+}
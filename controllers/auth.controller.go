@@ -35,6 +35,11 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if err := services.ProvisionUserSchema(createdUser.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare lab environment"})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"user": createdUser})
 }
 
@@ -59,6 +64,11 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if err := services.ProvisionUserSchema(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare lab environment"})
+		return
+	}
+
 	token, err := auth.GenerateToken(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetChallenges(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	challenges, err := services.GetChallenges(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve challenges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenges)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/collaborator"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetCollaborator(c *gin.Context) {
+	config := collaborator.CurrentConfig()
+	if !config.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "The collaborator is not enabled on this lab"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+	token, err := services.GetOrCreateCollaboratorToken(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collaborator token"})
+		return
+	}
+
+	response := gin.H{"token": token}
+	if config.HTTPPort != 0 {
+		response["http"] = gin.H{
+			"url":     fmt.Sprintf("http://%s:%d/%s/", config.Host, config.HTTPPort, token),
+			"example": fmt.Sprintf("http://%s:%d/%s/<data>", config.Host, config.HTTPPort, token),
+		}
+	}
+	if config.DNSPort != 0 {
+		response["dns"] = gin.H{
+			"server":  fmt.Sprintf("%s:%d", config.Host, config.DNSPort),
+			"domain":  fmt.Sprintf("%s.%s", token, config.Domain),
+			"example": fmt.Sprintf("<data>.%s.%s", token, config.Domain),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func GetCollaboratorHits(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	page, perPage := parsePagination(c)
+
+	hits, total, err := services.GetCollaboratorHits(user.ID, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collaborator hits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     hits,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+	})
+}
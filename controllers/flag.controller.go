@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func SubmitFlag(c *gin.Context) {
+	var requestBody struct {
+		ChallengeID string `json:"challenge_id" binding:"required"`
+		Flag        string `json:"flag" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+	_, flag, err := services.SubmitFlag(user.ID, requestBody.ChallengeID, requestBody.Flag)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check flag"})
+		return
+	}
+
+	if flag == nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "Incorrect flag, keep trying!"})
+		return
+	}
+
+	solve, isNewSolve, err := services.RecordSolve(user.ID, flag.Name)
+	if errors.Is(err, services.ErrChallengeNotFound) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Correct flag!", "flag": flag})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record solve"})
+		return
+	}
+
+	if !isNewSolve {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Correct flag, but you have already solved this challenge.", "flag": flag})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Correct flag!", "flag": flag, "solve": solve})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetUnlockedHints(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	challengeID := c.Param("id")
+
+	if _, err := services.GetChallengeByID(challengeID); err != nil {
+		respondHintError(c, err, "Failed to retrieve hints")
+		return
+	}
+
+	hints, err := services.GetUnlockedHints(user.ID, challengeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve hints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hints)
+}
+
+func UnlockNextHint(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	challengeID := c.Param("id")
+
+	hint, remaining, err := services.UnlockNextHint(user.ID, challengeID)
+	if err != nil {
+		respondHintError(c, err, "Failed to unlock hint")
+		return
+	}
+
+	challenge, err := services.GetChallengeByID(challengeID)
+	if err != nil {
+		respondHintError(c, err, "Failed to unlock hint")
+		return
+	}
+
+	penalty, err := services.GetHintPenalty(user.ID, challengeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock hint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hint":             hint,
+		"remaining_hints":  remaining,
+		"penalty":          penalty,
+		"points_available": max(challenge.Points-penalty, 0),
+	})
+}
+
+func GetHints(c *gin.Context) {
+	challengeID := c.Param("id")
+
+	if _, err := services.GetChallengeByID(challengeID); err != nil {
+		respondHintError(c, err, "Failed to retrieve hints")
+		return
+	}
+
+	hints, err := services.GetHints(challengeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve hints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hints)
+}
+
+func CreateHint(c *gin.Context) {
+	var hint models.Hint
+	if err := c.ShouldBindJSON(&hint); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hint.ChallengeID = c.Param("id")
+
+	createdHint, err := services.CreateHint(hint)
+	if err != nil {
+		respondHintError(c, err, "Failed to create hint")
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdHint)
+}
+
+func UpdateHint(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("hint_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hint ID"})
+		return
+	}
+
+	var hint models.Hint
+	if err := c.ShouldBindJSON(&hint); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hint.ChallengeID = c.Param("id")
+
+	if err := services.UpdateHint(id, hint); err != nil {
+		respondHintError(c, err, "Failed to update hint")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hint updated successfully"})
+}
+
+func DeleteHint(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("hint_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hint ID"})
+		return
+	}
+
+	if err := services.DeleteHint(c.Param("id"), id); err != nil {
+		respondHintError(c, err, "Failed to delete hint")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hint deleted successfully"})
+}
+
+func respondHintError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrChallengeNotFound), errors.Is(err, services.ErrHintNotFound), errors.Is(err, services.ErrNoMoreHints):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
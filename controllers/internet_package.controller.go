@@ -1,16 +1,23 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
 	"github.com/noverdy/sqli-demo-lab/models"
 	"github.com/noverdy/sqli-demo-lab/services"
 )
 
 func BuyInternetPackage(c *gin.Context) {
-	time.Sleep(1 * time.Second)
+	if lab.CurrentDifficulty() == lab.DifficultyHard {
+		time.Sleep(1 * time.Second)
+	}
 
 	var requestBody struct {
 		ID string `json:"package_id"`
@@ -21,25 +28,164 @@ func BuyInternetPackage(c *gin.Context) {
 		return
 	}
 
-	_, err := services.CheckInternetPackageExists(requestBody.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check package"})
+	ctx := c.Request.Context()
+	labRequest := newLabRequest(c)
+	mode := lab.CurrentMode(lab.PathBuyInternetPackage)
+	c.Header("X-Lab-Mode", string(mode))
+
+	// Only the safe path records a purchase. On the vulnerable path, whether
+	// an order was created would leak the result of the injected check on
+	// levels that are meant to be blind.
+	if mode == lab.ModeSecure {
+		order, err := services.CreateOrder(ctx, labRequest.UserID, requestBody.ID)
+		if errors.Is(err, services.ErrInternetPackageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Internet package not found"})
+			return
+		}
+		if errors.Is(err, services.ErrInternetPackageInactive) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Internet package is no longer available"})
+			return
+		}
+		if errors.Is(err, services.ErrInsufficientFunds) {
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Insufficient wallet balance, please top up first"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "The internet package purchase has been processed.", "order": order})
 		return
 	}
 
+	switch lab.CurrentDifficulty() {
+	case lab.DifficultyEasy:
+		_, err := services.CheckInternetPackageExists(ctx, labRequest, requestBody.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+	case lab.DifficultyMedium:
+		exists, err := services.CheckInternetPackageExists(ctx, labRequest, requestBody.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check package"})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Internet package not found"})
+			return
+		}
+
+	case lab.DifficultyInsane:
+		// The check runs after the response is sent, so neither its errors
+		// nor its duration can be observed by the client. It must not be
+		// cancelled when the client goes away, or out-of-band payloads would
+		// never fire.
+		release := middlewares.DetachThrottleSlot(c)
+		go func() {
+			defer release()
+			_, _ = services.CheckInternetPackageExists(context.WithoutCancel(ctx), labRequest, requestBody.ID)
+		}()
+
+	default:
+		_, err := services.CheckInternetPackageExists(ctx, labRequest, requestBody.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check package"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "The internet package purchase has been processed."})
 }
 
+func PreviewPackageExistsQuery(c *gin.Context) {
+	packageID := c.Query("package_id")
+
+	vulnerableQuery, _ := services.BuildPackageExistsQuery(lab.ModeVulnerable, packageID)
+	secureQuery, secureArgs := services.BuildPackageExistsQuery(lab.ModeSecure, packageID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"input":        packageID,
+		"current_mode": lab.CurrentMode(lab.PathBuyInternetPackage),
+		"strategies": gin.H{
+			"vulnerable": gin.H{
+				"query":       vulnerableQuery,
+				"args":        []any{},
+				"explanation": "The input is formatted into the SQL text, so quotes in it can end the string literal and change the statement.",
+			},
+			"secure": gin.H{
+				"query":       secureQuery,
+				"args":        secureArgs,
+				"explanation": "The SQL text never changes. The input is sent separately as a bound parameter and is always treated as a value.",
+			},
+		},
+	})
+}
+
 func GetAllInternetPackages(c *gin.Context) {
-	searchQuery := c.Query("q")
-	packages, err := services.GetAllInternetPackages(searchQuery)
+	filter := models.InternetPackageFilter{
+		Search:      c.Query("q"),
+		Category:    c.Query("category"),
+		Active:      c.Query("active"),
+		MinQuota:    c.Query("min_quota"),
+		MaxQuota:    c.Query("max_quota"),
+		MinValidity: c.Query("min_validity"),
+		MaxValidity: c.Query("max_validity"),
+		Sort:        c.Query("sort"),
+		Order:       c.Query("order"),
+		Limit:       c.Query("limit"),
+		Offset:      c.Query("offset"),
+	}
+	c.Header("X-Lab-Mode", string(lab.CurrentMode(lab.PathListInternetPackages)))
+
+	packages, err := services.GetAllInternetPackages(c.Request.Context(), newLabRequest(c), filter)
+	if errors.Is(err, services.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort or order, sort by name, price, quota_gb, validity_days, created_at or updated_at in asc or desc order"})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter, active must be true or false and quota and validity bounds must be non-negative numbers"})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPagination) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit or offset, limit must be between 1 and 100 and offset must not be negative"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve internet packages"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": labErrorMessage(err, "Failed to retrieve internet packages")})
 		return
 	}
+
+	// The body stays a plain array, so the total travels in a header.
+	if lab.CurrentMode(lab.PathPaginateInternetPackages) == lab.ModeSecure {
+		total, err := services.CountInternetPackages(c.Request.Context(), filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count internet packages"})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+	}
 	c.JSON(http.StatusOK, packages)
 }
 
+func GetInternetPackage(c *gin.Context) {
+	c.Header("X-Lab-Mode", string(lab.CurrentMode(lab.PathGetInternetPackage)))
+
+	pkg, err := services.GetInternetPackage(c.Request.Context(), newLabRequest(c), c.Param("id"))
+	if errors.Is(err, services.ErrInternetPackageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Internet package not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": labErrorMessage(err, "Failed to retrieve internet package")})
+		return
+	}
+
+	c.JSON(http.StatusOK, pkg)
+}
+
 func CreateInternetPackage(c *gin.Context) {
 	var pkg models.InternetPackage
 	if err := c.ShouldBindJSON(&pkg); err != nil {
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+	"github.com/noverdy/sqli-demo-lab/throttle"
+)
+
+func newLabRequest(c *gin.Context) services.LabRequest {
+	return services.LabRequest{
+		UserID:   c.MustGet("user").(models.User).ID,
+		SourceIP: c.ClientIP(),
+		Endpoint: c.FullPath(),
+		Schema:   c.GetString("schema"),
+	}
+}
+
+// labErrorMessage only reveals database errors on the easy level.
+func labErrorMessage(err error, fallback string) string {
+	if lab.CurrentDifficulty() == lab.DifficultyEasy {
+		return err.Error()
+	}
+	return fallback
+}
+
+func GetLabModes(c *gin.Context) {
+	c.JSON(http.StatusOK, lab.CodePaths())
+}
+
+func SetLabMode(c *gin.Context) {
+	var requestBody struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	mode, err := lab.ParseMode(requestBody.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	path := c.Param("path")
+	if err := lab.SetMode(path, mode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+	log.Printf("Lab mode of %s switched to %s by %s", path, mode, user.Email)
+
+	c.JSON(http.StatusOK, lab.CodePath{Path: path, Mode: mode})
+}
+
+func ResetLab(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	log.Printf("Lab reset requested by %s", user.Email)
+
+	adminPassword, err := services.ResetLab()
+	if err != nil {
+		log.Printf("Lab reset failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset the lab"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "The lab has been reset. Participant accounts and solves were kept, the admin password has changed.",
+		"admin_password": adminPassword,
+	})
+}
+
+func GetThrottleUsage(c *gin.Context) {
+	config := throttle.CurrentConfig()
+	c.JSON(http.StatusOK, gin.H{
+		"per_user":      config.PerUser,
+		"per_ip":        config.PerIP,
+		"queue_timeout": config.QueueTimeout.String(),
+		"buy":           throttle.Buy.Usage(),
+	})
+}
+
+func GetLabScenarios(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"stacked_queries": lab.StackedQueriesEnabled()})
+}
+
+func SetLabScenarios(c *gin.Context) {
+	var requestBody struct {
+		StackedQueries *bool `json:"stacked_queries" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	lab.SetStackedQueries(*requestBody.StackedQueries)
+
+	user := c.MustGet("user").(models.User)
+	log.Printf("Stacked queries set to %t by %s", *requestBody.StackedQueries, user.Email)
+
+	c.JSON(http.StatusOK, gin.H{"stacked_queries": lab.StackedQueriesEnabled()})
+}
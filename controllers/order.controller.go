@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetOrders(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	filter := models.OrderFilter{UserID: user.ID}
+	filter.Page, filter.PerPage = parsePagination(c)
+
+	listOrders(c, filter)
+}
+
+func GetOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+	order, err := services.GetOrder(user.ID, id)
+	if errors.Is(err, services.ErrOrderNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func GetAllOrders(c *gin.Context) {
+	filter := models.OrderFilter{
+		PackageID: c.Query("package_id"),
+		Status:    c.Query("status"),
+	}
+
+	filter.Page, filter.PerPage = parsePagination(c)
+
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		filter.UserID = id
+	}
+
+	if from := c.Query("from"); from != "" {
+		value, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, use YYYY-MM-DD"})
+			return
+		}
+		filter.From = &value
+	}
+
+	// to is inclusive, so the whole day counts.
+	if to := c.Query("to"); to != "" {
+		value, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, use YYYY-MM-DD"})
+			return
+		}
+		value = value.AddDate(0, 0, 1)
+		filter.To = &value
+	}
+
+	listOrders(c, filter)
+}
+
+func listOrders(c *gin.Context, filter models.OrderFilter) {
+	orders, total, err := services.GetOrders(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     orders,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+		"total":    total,
+	})
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetQueryLogs(c *gin.Context) {
+	filter := models.QueryLogFilter{
+		SourceIP: c.Query("ip"),
+		Endpoint: c.Query("endpoint"),
+		Mode:     c.Query("mode"),
+		Search:   c.Query("q"),
+	}
+
+	filter.Page, filter.PerPage = parsePagination(c)
+
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		filter.UserID = id
+	}
+
+	if hasError := c.Query("has_error"); hasError != "" {
+		value, err := strconv.ParseBool(hasError)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid has_error"})
+			return
+		}
+		filter.HasError = &value
+	}
+
+	if blocked := c.Query("blocked"); blocked != "" {
+		value, err := strconv.ParseBool(blocked)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocked"})
+			return
+		}
+		filter.Blocked = &value
+	}
+
+	logs, total, err := services.GetQueryLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve query logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     logs,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+		"total":    total,
+	})
+}
+
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 50
+	}
+	if perPage > 200 {
+		perPage = 200
+	}
+
+	return page, perPage
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetPurchaseReport(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	c.Header("X-Lab-Mode", string(lab.CurrentMode(lab.PathPurchaseReport)))
+
+	report, err := services.GeneratePurchaseReport(c.Request.Context(), newLabRequest(c), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": labErrorMessage(err, "Failed to generate purchase report")})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+func GetScoreboard(c *gin.Context) {
+	scoreboard, err := services.GetScoreboard()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scoreboard"})
+		return
+	}
+	c.JSON(http.StatusOK, scoreboard)
+}
+
+func StreamScoreboard(c *gin.Context) {
+	updates, unsubscribe := services.SubscribeScoreboard()
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	sendScoreboard := func() bool {
+		scoreboard, err := services.GetScoreboard()
+		if err != nil {
+			log.Printf("Failed to retrieve scoreboard for stream: %v", err)
+			return true
+		}
+		c.SSEvent("scoreboard", scoreboard)
+		return true
+	}
+
+	sendScoreboard()
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-updates:
+			return sendScoreboard()
+		case <-keepAlive.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+// maxTopUp keeps simulated top-ups within what the balance column can hold.
+const maxTopUp = 10000000
+
+func GetWallet(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	wallet, err := services.GetWallet(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve wallet"})
+		return
+	}
+
+	page, perPage := parsePagination(c)
+	transactions, total, err := services.GetWalletTransactions(user.ID, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve wallet transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance":    wallet.Balance,
+		"updated_at": wallet.UpdatedAt,
+		"data":       transactions,
+		"page":       page,
+		"per_page":   perPage,
+		"total":      total,
+	})
+}
+
+func TopUpWallet(c *gin.Context) {
+	var requestBody struct {
+		Amount float64 `json:"amount" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body, amount must be a positive number"})
+		return
+	}
+	if requestBody.Amount > maxTopUp {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must not exceed 10000000"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+	wallet, err := services.TopUpWallet(c.Request.Context(), user.ID, requestBody.Amount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to top up wallet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
@@ -1,33 +1,82 @@
 package db
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
 )
 
 var DB *sql.DB
 
+// RestrictedRole is the group role created by migration 0017. The login
+// role of every participant schema is a member of it.
+const RestrictedRole = "lab_restricted"
+
+// labPools holds the pool of every participant role that ran a lab query,
+// keyed by schema. See labPool.
+var (
+	labPoolsMu sync.Mutex
+	labPools   = map[string]*sql.DB{}
+)
+
+var queryTimeout time.Duration
+
+var (
+	restrictedRoleDisabled bool
+	restrictedPassword     string
+)
+
+type Executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 func InitDB() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	sslmode := os.Getenv("DB_SSLMODE")
+	dialect, err = parseDialect(os.Getenv("DB_DRIVER"))
+	if err != nil {
+		log.Fatalf("Invalid database driver: %v", err)
+	}
 
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s", user, password, host, port, dbname, sslmode)
+	queryTimeout, err = parseQueryTimeout(os.Getenv("DB_QUERY_TIMEOUT"))
+	if err != nil {
+		log.Fatalf("Invalid query timeout: %v", err)
+	}
 
-	DB, err = sql.Open("pgx", dsn)
+	if err := initRestrictedRole(); err != nil {
+		log.Fatalf("Invalid restricted role settings: %v", err)
+	}
+
+	switch dialect {
+	case DialectSQLite:
+		DB, err = openSQLite()
+	case DialectMySQL:
+		DB, err = openMySQL()
+	default:
+		DB, err = openPostgres()
+	}
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
@@ -36,5 +85,203 @@ func InitDB() {
 		log.Fatalf("Failed to ping the database: %v", err)
 	}
 
-	log.Println("Database connection established")
+	log.Printf("Database connection established (%s)", dialect)
+}
+
+func parseQueryTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 30 * time.Second, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("DB_QUERY_TIMEOUT must be a duration such as 30s, or 0 to disable it, got %q", value)
+	}
+	return timeout, nil
+}
+
+// WithQueryTimeout bounds ctx by DB_QUERY_TIMEOUT, so that a single sleep
+// payload cannot hold on to a pooled connection for longer than that.
+func WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, queryTimeout)
+}
+
+func openPostgres() (*sql.DB, error) {
+	return sql.Open("pgx", postgresDSN(os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD")))
+}
+
+func postgresDSN(user, password string) string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     net.JoinHostPort(os.Getenv("DB_HOST"), os.Getenv("DB_PORT")),
+		Path:     os.Getenv("DB_NAME"),
+		RawQuery: url.Values{"sslmode": {os.Getenv("DB_SSLMODE")}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// initRestrictedRole reads DB_RESTRICTED_ROLE and DB_RESTRICTED_PASSWORD.
+// The password is only used to derive those of the participant roles, and
+// nothing but the server itself logs in as them, so without a password a
+// random one is generated. The server sets the derived passwords at startup.
+func initRestrictedRole() error {
+	if value := os.Getenv("DB_RESTRICTED_ROLE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("DB_RESTRICTED_ROLE must be true or false, got %q", value)
+		}
+		restrictedRoleDisabled = !enabled
+	}
+
+	restrictedPassword = os.Getenv("DB_RESTRICTED_PASSWORD")
+	if restrictedPassword != "" {
+		return nil
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	restrictedPassword = hex.EncodeToString(b)
+	return nil
+}
+
+// ParticipantRolePassword returns the password of the login role of a
+// participant schema, derived from DB_RESTRICTED_PASSWORD so that none has
+// to be stored.
+func ParticipantRolePassword(role string) string {
+	mac := hmac.New(sha256.New, []byte(restrictedPassword))
+	mac.Write([]byte(role))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RestrictedRoleEnabled reports whether lab queries run as the role of the
+// participant schema, a member of RestrictedRole.
+// SQLite has no roles, and MySQL runs everything as DB_USER for now.
+func RestrictedRoleEnabled() bool {
+	return dialect == DialectPostgres && !restrictedRoleDisabled
+}
+
+// labPool returns the pool the lab queries of schema run on. On Postgres
+// every participant schema has a login role of the same name that can use
+// no other participant schema, and each gets a pool that logs in as it. The
+// pools only connect on first use, and drop idle connections quickly since
+// there can be one per participant.
+func labPool(schema string) (*sql.DB, error) {
+	if !RestrictedRoleEnabled() {
+		return DB, nil
+	}
+
+	labPoolsMu.Lock()
+	defer labPoolsMu.Unlock()
+
+	if pool, ok := labPools[schema]; ok {
+		return pool, nil
+	}
+	pool, err := sql.Open("pgx", postgresDSN(schema, ParticipantRolePassword(schema)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database as %s: %v", schema, err)
+	}
+	pool.SetMaxIdleConns(1)
+	pool.SetConnMaxIdleTime(time.Minute)
+	labPools[schema] = pool
+	return pool, nil
+}
+
+// WithSearchPath runs fn on a connection of the participant role of schema
+// whose search path points at schema. Cancelling ctx aborts the running
+// query.
+func WithSearchPath(ctx context.Context, schema string, fn func(conn *sql.Conn) error) error {
+	pool, err := labPool(schema)
+	if err != nil {
+		return err
+	}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if !SupportsSchemas() {
+		return fn(conn)
+	}
+
+	_, err = conn.ExecContext(ctx, "SET search_path TO "+pgx.Identifier{schema}.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn(conn)
+
+	// A stacked query can leave the session in a state we cannot reset
+	// (e.g. an aborted transaction), so such connections are discarded
+	// instead of being handed back to the pool. The reset must also run
+	// after ctx has been cancelled.
+	if _, err := conn.ExecContext(context.Background(), "RESET search_path"); err != nil {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+
+	return fnErr
+}
+
+// WithFlags runs fn with a temporary flags table on conn that holds only
+// flags, keyed by name. A temporary table is private to its connection and
+// hides a flags table of the same name, so a query can only read the flags
+// it was handed. The table is dropped again afterwards, and a connection
+// where that fails is discarded.
+func WithFlags(ctx context.Context, conn *sql.Conn, flags map[string]string, fn func() error) error {
+	create := "CREATE TEMP TABLE flags (name VARCHAR(100) NOT NULL, value VARCHAR(255) NOT NULL)"
+	drop := "DROP TABLE IF EXISTS pg_temp.flags"
+	switch dialect {
+	case DialectSQLite:
+		drop = "DROP TABLE IF EXISTS temp.flags"
+	case DialectMySQL:
+		create = "CREATE TEMPORARY TABLE flags (name VARCHAR(100) NOT NULL, value VARCHAR(255) NOT NULL)"
+		drop = "DROP TEMPORARY TABLE IF EXISTS flags"
+	}
+
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return err
+	}
+	for name, value := range flags {
+		if _, err := conn.ExecContext(ctx, "INSERT INTO flags (name, value) VALUES ($1, $2)", name, value); err != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+			return err
+		}
+	}
+
+	fnErr := fn()
+
+	if _, err := conn.ExecContext(context.Background(), drop); err != nil {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+
+	return fnErr
+}
+
+// queryStackedPostgres sends query over the simple query protocol, which
+// executes every statement in it.
+func queryStackedPostgres(ctx context.Context, conn *sql.Conn, query string) ([][][]string, error) {
+	var results []*pgconn.Result
+	err := conn.Raw(func(driverConn any) error {
+		var err error
+		results, err = driverConn.(*stdlib.Conn).Conn().PgConn().Exec(ctx, query).ReadAll()
+		return err
+	})
+
+	var texts [][][]string
+	for _, result := range results {
+		rows := make([][]string, len(result.Rows))
+		for i, row := range result.Rows {
+			rows[i] = make([]string, len(row))
+			for j, value := range row {
+				rows[i][j] = string(value)
+			}
+		}
+		texts = append(texts, rows)
+	}
+	return texts, err
 }
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+	DialectMySQL    Dialect = "mysql"
+)
+
+var dialect = DialectPostgres
+
+var ErrMultipleStatements = errors.New("cannot execute multiple statements in a single query")
+
+func parseDialect(value string) (Dialect, error) {
+	switch Dialect(strings.ToLower(strings.TrimSpace(value))) {
+	case "", DialectPostgres:
+		return DialectPostgres, nil
+	case DialectSQLite:
+		return DialectSQLite, nil
+	case DialectMySQL, "mariadb":
+		return DialectMySQL, nil
+	}
+	return "", fmt.Errorf("DB_DRIVER must be one of postgres, sqlite or mysql, got %q", value)
+}
+
+func CurrentDialect() Dialect {
+	return dialect
+}
+
+// SupportsSchemas reports whether each participant can get a schema of
+// their own. Only Postgres does, everyone shares one database elsewhere.
+func SupportsSchemas() bool {
+	return dialect == DialectPostgres
+}
+
+func MigrationsDir() string {
+	switch dialect {
+	case DialectSQLite:
+		return "./migrations/sqlite"
+	case DialectMySQL:
+		return "./migrations/mysql"
+	}
+	return "./migrations"
+}
+
+// ILike returns the case-insensitive LIKE operator. LIKE already ignores
+// case in SQLite (for ASCII) and under MySQL's default collations.
+func ILike() string {
+	if dialect == DialectPostgres {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// TextCast casts expr to a string type.
+func TextCast(expr string) string {
+	if dialect == DialectMySQL {
+		return "CAST(" + expr + " AS CHAR)"
+	}
+	return "CAST(" + expr + " AS TEXT)"
+}
+
+func QuoteIdentifier(parts ...string) string {
+	if dialect != DialectMySQL {
+		return pgx.Identifier(parts).Sanitize()
+	}
+
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+	}
+	return strings.Join(quoted, ".")
+}
+
+// OnConflictDoNothing returns the clause that turns an INSERT into a no-op
+// when it would violate the unique key on columns.
+func OnConflictDoNothing(columns ...string) string {
+	if dialect == DialectMySQL {
+		return fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", columns[0], columns[0])
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(columns, ", "))
+}
+
+// OnConflictUpdate returns the clause that overwrites updates with the
+// inserted values when an INSERT would violate the unique key on conflict.
+func OnConflictUpdate(conflict []string, updates ...string) string {
+	assignments := make([]string, len(updates))
+	for i, column := range updates {
+		if dialect == DialectMySQL {
+			assignments[i] = fmt.Sprintf("%s = VALUES(%s)", column, column)
+		} else {
+			assignments[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+		}
+	}
+
+	if dialect == DialectMySQL {
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(conflict, ", "), strings.Join(assignments, ", "))
+}
+
+// InsertID runs an INSERT into a table with a generated integer id and
+// returns that id, or sql.ErrNoRows when a conflict clause skipped the row.
+// MySQL has no RETURNING, so the id comes from the driver there.
+func InsertID(exec Executor, query string, args ...any) (int, error) {
+	if dialect != DialectMySQL {
+		var id int
+		err := exec.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := exec.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, sql.ErrNoRows
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// NewUUID returns a random version 4 UUID for tables whose id the database
+// cannot hand back after an insert.
+func NewUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// SingleStatementArgs makes the driver refuse to run anything after the
+// first statement of query. Postgres does so on the extended protocol,
+// which is forced explicitly here, and MySQL does so unless multi
+// statements are switched on. The SQLite driver runs every statement it is
+// given, so the query is checked up front instead.
+func SingleStatementArgs(query string, args []any) ([]any, error) {
+	switch dialect {
+	case DialectSQLite:
+		if err := CheckSingleStatement(query); err != nil {
+			return nil, err
+		}
+		return args, nil
+	case DialectMySQL:
+		return args, nil
+	}
+	return append([]any{pgx.QueryExecModeExec}, args...), nil
+}
+
+// CheckSingleStatement rejects queries with more than one statement on
+// dialects whose driver would otherwise run them all.
+func CheckSingleStatement(query string) error {
+	if dialect == DialectSQLite && len(splitStatements(query)) > 1 {
+		return ErrMultipleStatements
+	}
+	return nil
+}
+
+// QueryStacked runs every statement in query and returns the rows of each
+// statement as text.
+func QueryStacked(ctx context.Context, conn *sql.Conn, query string) ([][][]string, error) {
+	if dialect == DialectPostgres {
+		return queryStackedPostgres(ctx, conn, query)
+	}
+	return queryStackedOneByOne(ctx, conn, query)
+}
+
+// NullTime scans timestamps that SQLite returns as text, which happens when
+// an aggregate such as MAX(created_at) drops the column's declared type.
+type NullTime struct {
+	sql.NullTime
+}
+
+var textTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+func (t *NullTime) Scan(value any) error {
+	text, ok := value.(string)
+	if !ok {
+		return t.NullTime.Scan(value)
+	}
+
+	for _, format := range textTimeFormats {
+		if parsed, err := time.Parse(format, text); err == nil {
+			t.Time, t.Valid = parsed, true
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as a timestamp", text)
+}
@@ -1,7 +1,6 @@
 package db
 
 import (
-	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -56,38 +55,114 @@ func LoadMigrations(dir string) ([]Migration, error) {
 	return migrations, nil
 }
 
-func ApplyMigrations(db *sql.DB, dir string) error {
-	migrations, err := LoadMigrations(dir)
+func ensureMigrationsTable(db Executor) error {
+	query := "CREATE TABLE IF NOT EXISTS schema_migrations (version VARCHAR(255) PRIMARY KEY, applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)"
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %v", err)
+	}
+	return nil
+}
+
+func isMigrationApplied(db Executor, version string) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM schema_migrations WHERE version = $1"
+	err := db.QueryRow(query, version).Scan(&count)
 	if err != nil {
+		return false, fmt.Errorf("failed to check migration %s: %v", version, err)
+	}
+	return count > 0, nil
+}
+
+// execScript runs a migration file. MySQL only takes one statement per
+// call, so its files are split first.
+func execScript(db Executor, script string) error {
+	if dialect != DialectMySQL {
+		_, err := db.Exec(script)
+		return err
+	}
+
+	for _, statement := range splitStatements(script) {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ApplyMigrations(db Executor, dir string) error {
+	if _, err := ApplyPendingMigrations(db, dir); err != nil {
 		return err
 	}
 
+	log.Println("All migrations applied successfully")
+	return nil
+}
+
+// ApplyPendingMigrations applies the migrations that are not recorded yet
+// and returns how many there were.
+func ApplyPendingMigrations(db Executor, dir string) (int, error) {
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ensureMigrationsTable(db); err != nil {
+		return 0, err
+	}
+
+	pending := 0
 	for _, migration := range migrations {
+		applied, err := isMigrationApplied(db, migration.Version)
+		if err != nil {
+			return pending, err
+		}
+		if applied {
+			continue
+		}
+
 		log.Printf("Applying migration: %s", migration.Version)
 
 		sqlBytes, err := ioutil.ReadFile(migration.UpSQL)
 		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %v", migration.UpSQL, err)
+			return pending, fmt.Errorf("failed to read migration file %s: %v", migration.UpSQL, err)
+		}
+
+		err = execScript(db, string(sqlBytes))
+		if err != nil {
+			return pending, fmt.Errorf("failed to apply migration %s: %v", migration.Version, err)
 		}
 
-		_, err = db.Exec(string(sqlBytes))
+		_, err = db.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", migration.Version)
 		if err != nil {
-			return fmt.Errorf("failed to apply migration %s: %v", migration.Version, err)
+			return pending, fmt.Errorf("failed to record migration %s: %v", migration.Version, err)
 		}
+		pending++
 	}
 
-	log.Println("All migrations applied successfully")
-	return nil
+	return pending, nil
 }
 
-func RollbackMigrations(db *sql.DB, dir string) error {
+func RollbackMigrations(db Executor, dir string) error {
 	migrations, err := LoadMigrations(dir)
 	if err != nil {
 		return err
 	}
 
+	if err := ensureMigrationsTable(db); err != nil {
+		return err
+	}
+
 	for i := len(migrations) - 1; i >= 0; i-- {
 		migration := migrations[i]
+		applied, err := isMigrationApplied(db, migration.Version)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			continue
+		}
+
 		log.Printf("Rolling back migration: %s", migration.Version)
 
 		sqlBytes, err := ioutil.ReadFile(migration.DownSQL)
@@ -95,10 +170,15 @@ func RollbackMigrations(db *sql.DB, dir string) error {
 			return fmt.Errorf("failed to read migration file %s: %v", migration.DownSQL, err)
 		}
 
-		_, err = db.Exec(string(sqlBytes))
+		err = execScript(db, string(sqlBytes))
 		if err != nil {
 			return fmt.Errorf("failed to rollback migration %s: %v", migration.Version, err)
 		}
+
+		_, err = db.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		if err != nil {
+			return fmt.Errorf("failed to record rollback of migration %s: %v", migration.Version, err)
+		}
 	}
 
 	log.Println("All migrations rolled back successfully")
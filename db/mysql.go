@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func openMySQL() (*sql.DB, error) {
+	config := mysql.NewConfig()
+	config.User = os.Getenv("DB_USER")
+	config.Passwd = os.Getenv("DB_PASSWORD")
+	config.Net = "tcp"
+	config.Addr = net.JoinHostPort(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"))
+	config.DBName = os.Getenv("DB_NAME")
+	config.ParseTime = true
+
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(rebindConnector{connector}), nil
+}
+
+// rebind rewrites the Postgres-style $n placeholders used throughout the
+// services into the ? placeholders MySQL understands. order holds the
+// number of the argument each ? refers to, since $n may repeat or appear
+// out of order.
+func rebind(query string) (string, []int) {
+	var out []byte
+	var order []int
+
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] == '\'' || query[i] == '"' || query[i] == '`':
+			quote := query[i]
+			start := i
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' && quote != '`' {
+					i++
+					continue
+				}
+				if query[i] == quote {
+					break
+				}
+			}
+			end := min(i+1, len(query))
+			out = append(out, query[start:end]...)
+
+		case query[i] == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			order = append(order, n)
+			out = append(out, '?')
+			i = j - 1
+
+		default:
+			out = append(out, query[i])
+		}
+	}
+
+	return string(out), order
+}
+
+func reorderArgs(order []int, args []driver.NamedValue) []driver.NamedValue {
+	if len(order) == 0 {
+		return args
+	}
+
+	reordered := make([]driver.NamedValue, len(order))
+	for i, n := range order {
+		if n < 1 || n > len(args) {
+			return args
+		}
+		reordered[i] = args[n-1]
+		reordered[i].Ordinal = i + 1
+	}
+	return reordered
+}
+
+type rebindConnector struct {
+	driver.Connector
+}
+
+func (c rebindConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rebindConn{conn}, nil
+}
+
+// rebindConn passes everything through to the MySQL connection, rewriting
+// placeholders on the way. Queries without arguments are sent untouched,
+// so payloads in the vulnerable queries reach the server as they are.
+type rebindConn struct {
+	driver.Conn
+}
+
+func (c *rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query, order := rebind(query)
+	stmt, err := c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &rebindStmt{stmt, order}, nil
+}
+
+func (c *rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) > 0 {
+		var order []int
+		query, order = rebind(query)
+		args = reorderArgs(order, args)
+	}
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		var order []int
+		query, order = rebind(query)
+		args = reorderArgs(order, args)
+	}
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *rebindConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *rebindConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *rebindConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *rebindConn) IsValid() bool {
+	return c.Conn.(driver.Validator).IsValid()
+}
+
+func (c *rebindConn) CheckNamedValue(value *driver.NamedValue) error {
+	return c.Conn.(driver.NamedValueChecker).CheckNamedValue(value)
+}
+
+type rebindStmt struct {
+	driver.Stmt
+	order []int
+}
+
+// NumInput is unknown because $n may repeat, so database/sql skips its
+// argument count check and leaves it to the server.
+func (s *rebindStmt) NumInput() int {
+	return -1
+}
+
+func (s *rebindStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, reorderArgs(s.order, args))
+}
+
+func (s *rebindStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, reorderArgs(s.order, args))
+}
+
+func (s *rebindStmt) CheckNamedValue(value *driver.NamedValue) error {
+	return s.Stmt.(driver.NamedValueChecker).CheckNamedValue(value)
+}
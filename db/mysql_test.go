@@ -0,0 +1,179 @@
+package db
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		order []int
+	}{
+		{
+			name:  "no placeholders",
+			query: "SELECT * FROM users",
+			want:  "SELECT * FROM users",
+		},
+		{
+			name:  "in order",
+			query: "SELECT * FROM users WHERE id = $1 AND email = $2",
+			want:  "SELECT * FROM users WHERE id = ? AND email = ?",
+			order: []int{1, 2},
+		},
+		{
+			name:  "reused",
+			query: "INSERT INTO t (a, b) VALUES ($1, (SELECT id FROM u WHERE token = $1))",
+			want:  "INSERT INTO t (a, b) VALUES (?, (SELECT id FROM u WHERE token = ?))",
+			order: []int{1, 1},
+		},
+		{
+			name:  "out of order",
+			query: "UPDATE t SET a = $2 WHERE id = $1",
+			want:  "UPDATE t SET a = ? WHERE id = ?",
+			order: []int{2, 1},
+		},
+		{
+			name:  "multiple digits",
+			query: "VALUES ($10, $2)",
+			want:  "VALUES (?, ?)",
+			order: []int{10, 2},
+		},
+		{
+			name:  "inside single quotes",
+			query: "SELECT '$1' FROM t WHERE id = $1",
+			want:  "SELECT '$1' FROM t WHERE id = ?",
+			order: []int{1},
+		},
+		{
+			name:  "inside double quotes and backticks",
+			query: "SELECT \"$1\", `$2` FROM t WHERE id = $3",
+			want:  "SELECT \"$1\", `$2` FROM t WHERE id = ?",
+			order: []int{3},
+		},
+		{
+			name:  "escaped quote",
+			query: `SELECT 'it\'s $1' WHERE id = $1`,
+			want:  `SELECT 'it\'s $1' WHERE id = ?`,
+			order: []int{1},
+		},
+		{
+			name:  "doubled quote",
+			query: "SELECT 'it''s $1' WHERE id = $1",
+			want:  "SELECT 'it''s $1' WHERE id = ?",
+			order: []int{1},
+		},
+		{
+			name:  "backslash in backticks",
+			query: "SELECT `a\\` WHERE id = $1",
+			want:  "SELECT `a\\` WHERE id = ?",
+			order: []int{1},
+		},
+		{
+			name:  "dollar without digit",
+			query: "SELECT '$' || $ || $a WHERE id = $1",
+			want:  "SELECT '$' || $ || $a WHERE id = ?",
+			order: []int{1},
+		},
+		{
+			name:  "unterminated quote",
+			query: "SELECT * WHERE id = $1 AND name = 'abc $2",
+			want:  "SELECT * WHERE id = ? AND name = 'abc $2",
+			order: []int{1},
+		},
+		{
+			name:  "trailing dollar",
+			query: "SELECT $1, $",
+			want:  "SELECT ?, $",
+			order: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, order := rebind(tt.query)
+			if got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("rebind(%q) order = %v, want %v", tt.query, order, tt.order)
+			}
+		})
+	}
+}
+
+func TestReorderArgs(t *testing.T) {
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: "a"},
+		{Ordinal: 2, Value: "b"},
+		{Ordinal: 3, Value: "c"},
+	}
+
+	tests := []struct {
+		name  string
+		order []int
+		want  []driver.NamedValue
+	}{
+		{
+			name:  "no order",
+			order: nil,
+			want:  args,
+		},
+		{
+			name:  "in order",
+			order: []int{1, 2, 3},
+			want:  args,
+		},
+		{
+			name:  "reversed",
+			order: []int{3, 2, 1},
+			want: []driver.NamedValue{
+				{Ordinal: 1, Value: "c"},
+				{Ordinal: 2, Value: "b"},
+				{Ordinal: 3, Value: "a"},
+			},
+		},
+		{
+			name:  "reused",
+			order: []int{2, 2, 1, 2},
+			want: []driver.NamedValue{
+				{Ordinal: 1, Value: "b"},
+				{Ordinal: 2, Value: "b"},
+				{Ordinal: 3, Value: "a"},
+				{Ordinal: 4, Value: "b"},
+			},
+		},
+		{
+			name:  "subset",
+			order: []int{3},
+			want:  []driver.NamedValue{{Ordinal: 1, Value: "c"}},
+		},
+		{
+			// Left to the server, which reports the wrong argument count.
+			name:  "out of range",
+			order: []int{1, 4},
+			want:  args,
+		},
+		{
+			name:  "zero",
+			order: []int{0},
+			want:  args,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reorderArgs(tt.order, args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reorderArgs(%v) = %v, want %v", tt.order, got, tt.want)
+			}
+		})
+	}
+
+	if args[0].Value != "a" || args[0].Ordinal != 1 {
+		t.Errorf("reorderArgs modified its input: %v", args)
+	}
+}
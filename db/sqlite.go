@@ -0,0 +1,50 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+func openSQLite() (*sql.DB, error) {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = "./lab.db"
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite", path)
+	return sql.Open("sqlite", dsn)
+}
+
+// The functions below stand in for the Postgres built-ins the migrations
+// and the time-based level rely on.
+func init() {
+	sqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return NewUUID()
+	})
+
+	// sleep(seconds) is the SQLite counterpart of pg_sleep.
+	sqlite.MustRegisterScalarFunction("sleep", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var seconds float64
+		switch value := args[0].(type) {
+		case int64:
+			seconds = float64(value)
+		case float64:
+			seconds = value
+		default:
+			return nil, fmt.Errorf("sleep expects a number of seconds")
+		}
+		// The driver cannot interrupt a Go function, so a sleep never
+		// outlasts the query timeout.
+		duration := time.Duration(seconds * float64(time.Second))
+		if queryTimeout > 0 {
+			duration = min(duration, queryTimeout)
+		}
+		time.Sleep(duration)
+		return int64(0), nil
+	})
+}
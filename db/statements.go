@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+)
+
+// queryStackedOneByOne runs the statements of query one at a time, for
+// drivers that will not take them in one go.
+func queryStackedOneByOne(ctx context.Context, conn *sql.Conn, query string) ([][][]string, error) {
+	var results [][][]string
+	for _, statement := range splitStatements(query) {
+		rows, err := conn.QueryContext(ctx, statement)
+		if err != nil {
+			return results, err
+		}
+
+		result, err := readRowsAsText(rows)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func readRowsAsText(rows *sql.Rows) ([][]string, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columns))
+		for i, value := range values {
+			row[i] = value.String
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// splitStatements splits query on the semicolons that end a statement,
+// skipping those inside string literals, quoted identifiers and comments.
+// Empty statements are dropped.
+func splitStatements(query string) []string {
+	var statements []string
+	start := 0
+
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] == '\'' || query[i] == '"' || query[i] == '`':
+			quote := query[i]
+			for i++; i < len(query); i++ {
+				// MySQL also escapes quotes with a backslash.
+				if dialect == DialectMySQL && query[i] == '\\' && quote != '`' {
+					i++
+					continue
+				}
+				if query[i] == quote {
+					if i+1 < len(query) && query[i+1] == quote {
+						i++
+						continue
+					}
+					break
+				}
+			}
+
+		// SQLite also quotes identifiers in brackets, with no escape.
+		case dialect == DialectSQLite && query[i] == '[':
+			end := strings.IndexByte(query[i+1:], ']')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+
+		case isLineComment(query[i:]):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+
+		case query[i] == ';':
+			statements = appendStatement(statements, query[start:i])
+			start = i + 1
+		}
+	}
+
+	return appendStatement(statements, query[start:])
+}
+
+func isLineComment(rest string) bool {
+	return strings.HasPrefix(rest, "--") || (dialect == DialectMySQL && strings.HasPrefix(rest, "#"))
+}
+
+func appendStatement(statements []string, statement string) []string {
+	if isBlankStatement(statement) {
+		return statements
+	}
+	return append(statements, strings.TrimSpace(statement))
+}
+
+// isBlankStatement reports whether statement holds nothing but whitespace
+// and comments, which Postgres does not count as a statement either.
+func isBlankStatement(statement string) bool {
+	for i := 0; i < len(statement); i++ {
+		switch {
+		case isLineComment(statement[i:]):
+			for i < len(statement) && statement[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				return true
+			}
+			i += end + 3
+
+		case !unicode.IsSpace(rune(statement[i])):
+			return false
+		}
+	}
+	return true
+}
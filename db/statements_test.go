@@ -0,0 +1,166 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    []string
+	}{
+		{
+			name:  "single",
+			query: "SELECT 1",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name:  "stacked",
+			query: "SELECT 1; SELECT 2;",
+			want:  []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:  "empty statements",
+			query: ";; SELECT 1 ;  ; ",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name:  "empty query",
+			query: "  ",
+			want:  nil,
+		},
+		{
+			name:  "semicolon in string",
+			query: "SELECT 'a;b'; SELECT 2",
+			want:  []string{"SELECT 'a;b'", "SELECT 2"},
+		},
+		{
+			name:  "doubled quote",
+			query: "SELECT 'it''s; fine'; SELECT 2",
+			want:  []string{"SELECT 'it''s; fine'", "SELECT 2"},
+		},
+		{
+			name:  "quoted identifiers",
+			query: "SELECT \"a;b\", `c;d` FROM t; SELECT 2",
+			want:  []string{"SELECT \"a;b\", `c;d` FROM t", "SELECT 2"},
+		},
+		{
+			name:  "line comment",
+			query: "SELECT 1 -- ; not a statement\n; SELECT 2",
+			want:  []string{"SELECT 1 -- ; not a statement", "SELECT 2"},
+		},
+		{
+			name:  "trailing line comment",
+			query: "SELECT 1; -- the rest is commented out",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name:  "block comment",
+			query: "SELECT /* ; */ 1; /* only a comment */; SELECT 2",
+			want:  []string{"SELECT /* ; */ 1", "SELECT 2"},
+		},
+		{
+			name:  "unterminated block comment",
+			query: "SELECT 1; /* SELECT 2; SELECT 3",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name:  "unterminated string",
+			query: "SELECT 1; SELECT 'a; SELECT 2",
+			want:  []string{"SELECT 1", "SELECT 'a; SELECT 2"},
+		},
+		{
+			name:    "backslash is literal outside mysql",
+			dialect: DialectSQLite,
+			query:   `SELECT 'a\'; SELECT 2`,
+			want:    []string{`SELECT 'a\'`, "SELECT 2"},
+		},
+		{
+			name:    "backslash escapes on mysql",
+			dialect: DialectMySQL,
+			query:   `SELECT 'a\'; b'; SELECT 2`,
+			want:    []string{`SELECT 'a\'; b'`, "SELECT 2"},
+		},
+		{
+			name:    "hash is not a comment outside mysql",
+			dialect: DialectSQLite,
+			query:   "SELECT 1 # x; SELECT 2",
+			want:    []string{"SELECT 1 # x", "SELECT 2"},
+		},
+		{
+			name:    "bracketed identifier on sqlite",
+			dialect: DialectSQLite,
+			query:   "SELECT 1 AS [a;b]; SELECT 2",
+			want:    []string{"SELECT 1 AS [a;b]", "SELECT 2"},
+		},
+		{
+			name:    "quote in bracketed identifier",
+			dialect: DialectSQLite,
+			query:   "SELECT * FROM t WHERE id = '' OR 1 IN (SELECT 1 AS [a's]); DELETE FROM users; --''",
+			want:    []string{"SELECT * FROM t WHERE id = '' OR 1 IN (SELECT 1 AS [a's])", "DELETE FROM users"},
+		},
+		{
+			name:    "unterminated bracketed identifier",
+			dialect: DialectSQLite,
+			query:   "SELECT [a; SELECT 2",
+			want:    []string{"SELECT [a; SELECT 2"},
+		},
+		{
+			name:    "brackets are not quotes outside sqlite",
+			dialect: DialectPostgres,
+			query:   "SELECT a[1]; SELECT ';'",
+			want:    []string{"SELECT a[1]", "SELECT ';'"},
+		},
+		{
+			name:    "hash comment on mysql",
+			dialect: DialectMySQL,
+			query:   "SELECT 1 # x; SELECT 2\n; SELECT 3; #",
+			want:    []string{"SELECT 1 # x; SELECT 2", "SELECT 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := dialect
+			if tt.dialect != "" {
+				dialect = tt.dialect
+			}
+			defer func() { dialect = previous }()
+
+			got := splitStatements(tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSingleStatement(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		wantErr bool
+	}{
+		{DialectSQLite, "SELECT * FROM t WHERE id = 'x'", false},
+		{DialectSQLite, "SELECT * FROM t WHERE id = 'x'; -- trailing comment", false},
+		{DialectSQLite, "SELECT * FROM t WHERE id = 'x'; DELETE FROM users", true},
+		{DialectSQLite, "SELECT * FROM t WHERE id = '' OR 1 IN (SELECT 1 AS [a's]); DELETE FROM users; --''", true},
+		{DialectPostgres, "SELECT 1; SELECT 2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			previous := dialect
+			dialect = tt.dialect
+			defer func() { dialect = previous }()
+
+			err := CheckSingleStatement(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckSingleStatement(%q) = %v, want error %t", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
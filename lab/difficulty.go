@@ -0,0 +1,37 @@
+package lab
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+	DifficultyInsane Difficulty = "insane"
+)
+
+var difficulty = DifficultyHard
+
+func InitializeDifficulty() error {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv("LAB_DIFFICULTY")))
+	if value == "" {
+		difficulty = DifficultyHard
+		return nil
+	}
+
+	switch Difficulty(value) {
+	case DifficultyEasy, DifficultyMedium, DifficultyHard, DifficultyInsane:
+		difficulty = Difficulty(value)
+		return nil
+	}
+	return fmt.Errorf("LAB_DIFFICULTY must be one of easy, medium, hard or insane, got %q", value)
+}
+
+func CurrentDifficulty() Difficulty {
+	return difficulty
+}
@@ -0,0 +1,65 @@
+package lab
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AllTables stands for every table of a participant schema in a Grants map.
+const AllTables = "*"
+
+// Grants maps a table to the privileges the restricted database role holds
+// on it, written as in a GRANT statement, e.g. "SELECT (id, name), UPDATE".
+type Grants struct {
+	Tables map[string]string
+	// ServerPrograms lets the role run COPY ... TO PROGRAM, which the
+	// out-of-band payloads of the insane level rely on.
+	ServerPrograms bool
+}
+
+// RoleGrants shapes what an injected query can reach on each level. Flags
+// are not part of it: participant schemas have no flags table, and every
+// query gets a temporary one with the flags of its own code path only.
+var RoleGrants = map[Difficulty]Grants{
+	DifficultyEasy: {
+		Tables: map[string]string{AllTables: "SELECT, INSERT, UPDATE, DELETE"},
+	},
+	DifficultyMedium: {
+		Tables: map[string]string{
+			"users":             "SELECT, UPDATE",
+			"internet_packages": "SELECT",
+			"visits":            "SELECT, INSERT",
+		},
+	},
+	DifficultyHard: {
+		Tables: map[string]string{
+			"users":             "SELECT (id, name, email, is_admin), UPDATE (is_admin)",
+			"internet_packages": "SELECT",
+			"visits":            "INSERT",
+		},
+	},
+	DifficultyInsane: {
+		Tables: map[string]string{
+			"users":             "SELECT (id, name, email, is_admin), UPDATE (is_admin)",
+			"internet_packages": "SELECT",
+			"visits":            "INSERT",
+		},
+		ServerPrograms: true,
+	},
+}
+
+// CurrentRoleGrants returns the grants of LAB_ROLE_GRANTS, which defaults
+// to the lab difficulty.
+func CurrentRoleGrants() (Grants, error) {
+	level := Difficulty(strings.ToLower(strings.TrimSpace(os.Getenv("LAB_ROLE_GRANTS"))))
+	if level == "" {
+		level = CurrentDifficulty()
+	}
+
+	grants, ok := RoleGrants[level]
+	if !ok {
+		return Grants{}, fmt.Errorf("LAB_ROLE_GRANTS must be one of easy, medium, hard or insane, got %q", level)
+	}
+	return grants, nil
+}
@@ -0,0 +1,112 @@
+package lab
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Mode string
+
+const (
+	ModeVulnerable Mode = "vulnerable"
+	ModeSecure     Mode = "secure"
+)
+
+const (
+	PathBuyInternetPackage       = "buy-internet-package"
+	PathListInternetPackages     = "list-internet-packages"
+	PathGetInternetPackage       = "get-internet-package"
+	PathPaginateInternetPackages = "paginate-internet-packages"
+	PathPurchaseReport           = "purchase-report"
+	PathVisitUserAgent           = "visit-user-agent"
+	PathVisitForwardedFor        = "visit-forwarded-for"
+	PathVisitVisitorID           = "visit-visitor-id"
+)
+
+var (
+	modesMu sync.RWMutex
+	modes   = map[string]Mode{
+		PathBuyInternetPackage:       ModeVulnerable,
+		PathListInternetPackages:     ModeVulnerable,
+		PathGetInternetPackage:       ModeVulnerable,
+		PathPaginateInternetPackages: ModeVulnerable,
+		PathPurchaseReport:           ModeVulnerable,
+		PathVisitUserAgent:           ModeVulnerable,
+		PathVisitForwardedFor:        ModeVulnerable,
+		PathVisitVisitorID:           ModeVulnerable,
+	}
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(value))) {
+	case ModeVulnerable:
+		return ModeVulnerable, nil
+	case ModeSecure:
+		return ModeSecure, nil
+	}
+	return "", fmt.Errorf("mode must be either vulnerable or secure, got %q", value)
+}
+
+func InitializeModes() error {
+	value := os.Getenv("LAB_MODE")
+	if value == "" {
+		return nil
+	}
+
+	mode, err := ParseMode(value)
+	if err != nil {
+		return fmt.Errorf("invalid LAB_MODE: %v", err)
+	}
+
+	modesMu.Lock()
+	defer modesMu.Unlock()
+	for path := range modes {
+		modes[path] = mode
+	}
+	return nil
+}
+
+func CurrentMode(path string) Mode {
+	modesMu.RLock()
+	defer modesMu.RUnlock()
+	return modes[path]
+}
+
+func SetMode(path string, mode Mode) error {
+	modesMu.Lock()
+	defer modesMu.Unlock()
+	if _, ok := modes[path]; !ok {
+		return fmt.Errorf("unknown code path %q", path)
+	}
+	modes[path] = mode
+	return nil
+}
+
+func IsCodePath(path string) bool {
+	modesMu.RLock()
+	defer modesMu.RUnlock()
+	_, ok := modes[path]
+	return ok
+}
+
+type CodePath struct {
+	Path string `json:"path"`
+	Mode Mode   `json:"mode"`
+}
+
+func CodePaths() []CodePath {
+	modesMu.RLock()
+	defer modesMu.RUnlock()
+
+	paths := make([]CodePath, 0, len(modes))
+	for path, mode := range modes {
+		paths = append(paths, CodePath{Path: path, Mode: mode})
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].Path < paths[j].Path
+	})
+	return paths
+}
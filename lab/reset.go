@@ -0,0 +1,23 @@
+package lab
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+func ResetInterval() (time.Duration, error) {
+	value := os.Getenv("LAB_RESET_INTERVAL")
+	if value == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("LAB_RESET_INTERVAL must be a duration such as 90m or 2h, got %q", value)
+	}
+	if interval < time.Minute {
+		return 0, fmt.Errorf("LAB_RESET_INTERVAL must be at least 1m, got %s", interval)
+	}
+	return interval, nil
+}
@@ -0,0 +1,56 @@
+package lab
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	stackedQueries atomic.Bool
+	tracking       bool
+)
+
+func InitializeScenarios() error {
+	enabled, err := parseToggle("LAB_STACKED_QUERIES")
+	if err != nil {
+		return err
+	}
+	stackedQueries.Store(enabled)
+
+	tracking, err = parseToggle("LAB_TRACKING")
+	return err
+}
+
+func parseToggle(key string) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", key, value)
+	}
+	return enabled, nil
+}
+
+// StackedQueriesEnabled reports whether the vulnerable buy query is sent
+// over the simple query protocol, where "; UPDATE ..." payloads run, rather
+// than the extended protocol, which rejects more than one statement.
+func StackedQueriesEnabled() bool {
+	return stackedQueries.Load()
+}
+
+func SetStackedQueries(enabled bool) {
+	stackedQueries.Store(enabled)
+}
+
+// TrackingEnabled reports whether API requests record their User-Agent,
+// X-Forwarded-For and visitor_id cookie, which opens header- and
+// cookie-sourced injection points. It is fixed at startup because the
+// middleware is only mounted when the router is built.
+func TrackingEnabled() bool {
+	return tracking
+}
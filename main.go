@@ -6,8 +6,14 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/noverdy/sqli-demo-lab/auth"
+	"github.com/noverdy/sqli-demo-lab/challenges"
+	"github.com/noverdy/sqli-demo-lab/collaborator"
 	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/lab"
 	"github.com/noverdy/sqli-demo-lab/routes"
+	"github.com/noverdy/sqli-demo-lab/services"
+	"github.com/noverdy/sqli-demo-lab/throttle"
+	"github.com/noverdy/sqli-demo-lab/waf"
 )
 
 func main() {
@@ -21,15 +27,82 @@ func main() {
 		log.Fatalf("Error initializing JWT secret: %v", err)
 	}
 
+	err = lab.InitializeDifficulty()
+	if err != nil {
+		log.Fatalf("Error initializing lab difficulty: %v", err)
+	}
+
+	err = lab.InitializeModes()
+	if err != nil {
+		log.Fatalf("Error initializing lab modes: %v", err)
+	}
+
+	err = lab.InitializeScenarios()
+	if err != nil {
+		log.Fatalf("Error initializing lab scenarios: %v", err)
+	}
+
+	err = waf.InitializeRuleSet()
+	if err != nil {
+		log.Fatalf("Error initializing WAF: %v", err)
+	}
+
+	err = throttle.InitializeConfig()
+	if err != nil {
+		log.Fatalf("Error initializing throttle: %v", err)
+	}
+
 	db.InitDB()
 	defer db.DB.Close()
 
+	err = challenges.InitializeCatalog()
+	if err != nil {
+		log.Fatalf("Error loading challenges: %v", err)
+	}
+
+	err = challenges.Sync(db.DB)
+	if err != nil {
+		log.Fatalf("Error syncing challenges: %v", err)
+	}
+
+	err = services.MigrateParticipantSchemas()
+	if err != nil {
+		log.Fatalf("Error migrating participant schemas: %v", err)
+	}
+
+	err = services.SyncRestrictedRole()
+	if err != nil {
+		log.Fatalf("Error setting up the restricted database role: %v", err)
+	}
+
+	err = collaborator.InitializeConfig()
+	if err != nil {
+		log.Fatalf("Error initializing collaborator: %v", err)
+	}
+	if collaborator.CurrentConfig().Enabled() {
+		err = collaborator.Start(services.RecordCollaboratorHit)
+		if err != nil {
+			log.Fatalf("Error starting collaborator: %v", err)
+		}
+	}
+
+	resetInterval, err := lab.ResetInterval()
+	if err != nil {
+		log.Fatalf("Error initializing lab reset: %v", err)
+	}
+	if resetInterval > 0 {
+		services.ScheduleLabReset(resetInterval)
+	}
+
 	port := os.Getenv("APP_PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	r := routes.SetupRouter()
+	r, err := routes.SetupRouter()
+	if err != nil {
+		log.Fatalf("Error setting up router: %v", err)
+	}
 
 	log.Printf("Server is running on port %s", port)
 	if err := r.Run(":" + port); err != nil {
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -12,39 +13,43 @@ import (
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
-			return
-		}
-
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token is required"})
-			c.Abort()
-			return
-		}
-
-		claims, err := auth.ValidateToken(token)
+		user, err := authenticate(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		user, err := services.GetUserByID(int(claims["user_id"].(float64)))
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
 		c.Set("user", user)
+		c.Set("schema", services.UserSchemaName(user.ID))
 		c.Next()
 	}
 }
 
+func authenticate(c *gin.Context) (models.User, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return models.User{}, errors.New("Authorization header is required")
+	}
+
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	if token == "" {
+		return models.User{}, errors.New("Bearer token is required")
+	}
+
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		return models.User{}, errors.New("Invalid token")
+	}
+
+	user, err := services.GetUserByID(int(claims["user_id"].(float64)))
+	if err != nil {
+		return models.User{}, errors.New("User not found")
+	}
+
+	return user, nil
+}
+
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, exists := c.Get("user")
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/throttle"
+)
+
+const (
+	throttleSlotKey     = "throttle_slot"
+	throttleDetachedKey = "throttle_detached"
+)
+
+// ThrottleMiddleware caps the requests in flight per user and per IP on the
+// routes it guards. It must run after AuthMiddleware.
+func ThrottleMiddleware(limiter *throttle.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(models.User)
+		slot, err := limiter.Acquire(c.Request.Context(), user.ID, c.ClientIP())
+		if errors.Is(err, throttle.ErrLimitExceeded) {
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many concurrent requests, please slow down"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Abort()
+			return
+		}
+
+		c.Set(throttleSlotKey, slot)
+		defer func() {
+			if !c.GetBool(throttleDetachedKey) {
+				slot.Release()
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// DetachThrottleSlot hands the request's slot over to work that outlives the
+// response, and returns the function that frees it once that work is done.
+func DetachThrottleSlot(c *gin.Context) func() {
+	slot, ok := c.Get(throttleSlotKey)
+	if !ok {
+		return func() {}
+	}
+	c.Set(throttleDetachedKey, true)
+	return slot.(*throttle.Slot).Release
+}
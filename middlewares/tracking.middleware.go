@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+)
+
+const visitorCookie = "visitor_id"
+
+// TrackingMiddleware records the User-Agent, X-Forwarded-For and visitor_id
+// cookie of authenticated requests into the visits table of the user's own
+// schema. It runs the handler first, so that the user AuthMiddleware put in
+// the context is known. Anonymous requests are not tracked, and a failed
+// insert is only logged, never turned into a failed request.
+func TrackingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		visitorID, err := c.Cookie(visitorCookie)
+		if err != nil {
+			visitorID, err = newVisitorID()
+			if err != nil {
+				log.Printf("Failed to generate visitor id: %v", err)
+			} else {
+				c.SetCookie(visitorCookie, visitorID, 60*60*24*365, "/", "", false, true)
+			}
+		}
+
+		c.Next()
+
+		value, exists := c.Get("user")
+		if !exists {
+			return
+		}
+		user := value.(models.User)
+
+		req := services.LabRequest{
+			UserID:   user.ID,
+			SourceIP: c.ClientIP(),
+			Endpoint: "tracking",
+			Schema:   c.GetString("schema"),
+		}
+		visit := models.Visit{
+			Path:         c.Request.URL.Path,
+			UserAgent:    c.GetHeader("User-Agent"),
+			ForwardedFor: c.GetHeader("X-Forwarded-For"),
+			VisitorID:    visitorID,
+		}
+
+		if lab.CurrentDifficulty() == lab.DifficultyInsane {
+			go recordVisit(context.WithoutCancel(c.Request.Context()), req, visit)
+			return
+		}
+		recordVisit(c.Request.Context(), req, visit)
+	}
+}
+
+func recordVisit(ctx context.Context, req services.LabRequest, visit models.Visit) {
+	if err := services.RecordVisit(ctx, req, visit); err != nil {
+		log.Printf("Failed to record visit of user %d on %s: %v", req.UserID, visit.Path, err)
+	}
+}
+
+func newVisitorID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
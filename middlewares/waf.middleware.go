@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/models"
+	"github.com/noverdy/sqli-demo-lab/services"
+	"github.com/noverdy/sqli-demo-lab/waf"
+)
+
+func WAFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleSet := waf.ActiveRuleSet()
+		if len(ruleSet.Rules) == 0 {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Abort()
+			return
+		}
+
+		var payload any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Next()
+			return
+		}
+
+		payload, field, value, rule := inspectPayload(ruleSet, "", payload)
+		if rule != nil {
+			incidentID := newIncidentID()
+			user := c.MustGet("user").(models.User)
+			log.Printf("WAF blocked request %s from %s (user %d) on %s: rule %q of rule set %q matched field %q", incidentID, c.ClientIP(), user.ID, c.FullPath(), rule.Name, ruleSet.Name, field)
+
+			ruleName := ruleSet.Name + "/" + rule.Name
+			entry := models.QueryLog{
+				UserID:   user.ID,
+				SourceIP: c.ClientIP(),
+				Endpoint: c.FullPath(),
+				Input:    value,
+				WAFRule:  &ruleName,
+			}
+			if err := services.CreateQueryLog(entry); err != nil {
+				log.Printf("Failed to record blocked request: %v", err)
+			}
+
+			c.Header("X-WAF-Incident-ID", incidentID)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       "Request blocked",
+				"message":     "Your request has been blocked by the web application firewall. If you believe this is a mistake, contact the administrator with the incident ID.",
+				"incident_id": incidentID,
+			})
+			c.Abort()
+			return
+		}
+
+		body, err = json.Marshal(payload)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+
+		c.Next()
+	}
+}
+
+func inspectPayload(ruleSet waf.RuleSet, field string, payload any) (any, string, string, *waf.Rule) {
+	switch value := payload.(type) {
+	case string:
+		rewritten, rule := ruleSet.Inspect(value)
+		return rewritten, field, value, rule
+
+	case map[string]any:
+		for key, item := range value {
+			rewritten, blockedField, blockedValue, rule := inspectPayload(ruleSet, key, item)
+			if rule != nil {
+				return payload, blockedField, blockedValue, rule
+			}
+			value[key] = rewritten
+		}
+
+	case []any:
+		for i, item := range value {
+			rewritten, blockedField, blockedValue, rule := inspectPayload(ruleSet, field, item)
+			if rule != nil {
+				return payload, blockedField, blockedValue, rule
+			}
+			value[i] = rewritten
+		}
+	}
+
+	return payload, field, "", nil
+}
+
+func newIncidentID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,4 +1,4 @@
-CREATE TABLE internet_packages (
+CREATE TABLE IF NOT EXISTS internet_packages (
     id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
     name VARCHAR(100) NOT NULL,
     description TEXT,
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS flag_submissions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    flag_id INT REFERENCES flags(id) ON DELETE SET NULL,
+    submission TEXT NOT NULL,
+    is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS query_logs (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source_ip VARCHAR(45) NOT NULL,
+    endpoint VARCHAR(100) NOT NULL,
+    input TEXT NOT NULL,
+    query TEXT NOT NULL,
+    duration_ms DOUBLE PRECISION NOT NULL,
+    row_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_logs_user_id ON query_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_query_logs_created_at ON query_logs (created_at);
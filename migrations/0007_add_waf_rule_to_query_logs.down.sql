@@ -0,0 +1 @@
+ALTER TABLE query_logs DROP COLUMN IF EXISTS waf_rule;
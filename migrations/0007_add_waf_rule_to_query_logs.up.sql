@@ -0,0 +1 @@
+ALTER TABLE query_logs ADD COLUMN IF NOT EXISTS waf_rule VARCHAR(100);
@@ -0,0 +1,10 @@
+CREATE TABLE hints (
+    id SERIAL PRIMARY KEY,
+    challenge_id VARCHAR(100) NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    content TEXT NOT NULL,
+    cost INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (challenge_id, position)
+);
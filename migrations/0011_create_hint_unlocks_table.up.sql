@@ -0,0 +1,7 @@
+CREATE TABLE hint_unlocks (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hint_id INT NOT NULL REFERENCES hints(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, hint_id)
+);
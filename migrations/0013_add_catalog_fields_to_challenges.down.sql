@@ -0,0 +1,2 @@
+ALTER TABLE challenges DROP COLUMN IF EXISTS endpoint;
+ALTER TABLE challenges DROP COLUMN IF EXISTS category;
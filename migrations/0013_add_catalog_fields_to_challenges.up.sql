@@ -0,0 +1,2 @@
+ALTER TABLE challenges ADD COLUMN category VARCHAR(100) NOT NULL DEFAULT 'web';
+ALTER TABLE challenges ADD COLUMN endpoint VARCHAR(255) NOT NULL DEFAULT '';
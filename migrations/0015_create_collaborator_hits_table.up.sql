@@ -0,0 +1,12 @@
+CREATE TABLE collaborator_hits (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(255) NOT NULL,
+    protocol VARCHAR(10) NOT NULL,
+    data TEXT NOT NULL,
+    source_ip VARCHAR(45) NOT NULL,
+    raw TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_collaborator_hits_user_id ON collaborator_hits (user_id);
@@ -0,0 +1,12 @@
+DO $$
+BEGIN
+    IF current_schema() LIKE 'lab\_user\_%' THEN
+        EXECUTE format('REVOKE ALL ON SCHEMA %I FROM lab_restricted', current_schema());
+        EXECUTE format('REVOKE ALL ON ALL TABLES IN SCHEMA %I FROM lab_restricted', current_schema());
+        EXECUTE format('REVOKE ALL ON ALL SEQUENCES IN SCHEMA %I FROM lab_restricted', current_schema());
+    ELSIF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'lab_restricted') THEN
+        DROP OWNED BY lab_restricted;
+        DROP ROLE lab_restricted;
+    END IF;
+END
+$$;
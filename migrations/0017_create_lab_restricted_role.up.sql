@@ -0,0 +1,17 @@
+-- Roles are shared by the whole cluster, while this migration also runs in
+-- every participant schema, so the role is only created once. It never logs
+-- in itself: the server gives every participant schema a login role of its
+-- own that is a member of this one.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'lab_restricted') THEN
+        CREATE ROLE lab_restricted NOLOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOREPLICATION;
+    END IF;
+
+    -- The server revokes this again and grants the schema to its own
+    -- participant role only, with the table grants of the level.
+    IF current_schema() LIKE 'lab\_user\_%' THEN
+        EXECUTE format('GRANT USAGE ON SCHEMA %I TO lab_restricted', current_schema());
+    END IF;
+END
+$$;
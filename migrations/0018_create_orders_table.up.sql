@@ -0,0 +1,12 @@
+CREATE TABLE orders (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    package_id uuid REFERENCES internet_packages(id) ON DELETE SET NULL,
+    package_name VARCHAR(100) NOT NULL,
+    price NUMERIC(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_orders_user_id ON orders (user_id);
+CREATE INDEX idx_orders_created_at ON orders (created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_internet_packages_category;
+ALTER TABLE internet_packages DROP COLUMN IF EXISTS is_active;
+ALTER TABLE internet_packages DROP COLUMN IF EXISTS category;
+ALTER TABLE internet_packages DROP COLUMN IF EXISTS validity_days;
+ALTER TABLE internet_packages DROP COLUMN IF EXISTS quota_gb;
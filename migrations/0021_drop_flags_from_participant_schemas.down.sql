@@ -0,0 +1,12 @@
+DO $$
+BEGIN
+    IF current_schema() LIKE 'lab\_user\_%' THEN
+        CREATE TABLE IF NOT EXISTS flags (
+            id SERIAL PRIMARY KEY,
+            name VARCHAR(100) UNIQUE NOT NULL,
+            value VARCHAR(255) UNIQUE NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        );
+    END IF;
+END
+$$;
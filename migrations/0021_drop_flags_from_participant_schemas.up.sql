@@ -0,0 +1,12 @@
+-- Participant schemas used to hold a copy of every flag, so one injection
+-- point could read the flags of all challenges. Lab queries now get a
+-- temporary flags table with the flags of their own code path instead. The
+-- catalog keeps its flags in public. CASCADE only drops the foreign key of
+-- flag_submissions, not the table.
+DO $$
+BEGIN
+    IF current_schema() LIKE 'lab\_user\_%' THEN
+        DROP TABLE IF EXISTS flags CASCADE;
+    END IF;
+END
+$$;
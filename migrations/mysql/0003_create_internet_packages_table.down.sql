@@ -0,0 +1 @@
+DROP TABLE IF EXISTS internet_packages;
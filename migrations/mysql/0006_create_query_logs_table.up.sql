@@ -0,0 +1,16 @@
+CREATE TABLE query_logs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    source_ip VARCHAR(45) NOT NULL,
+    endpoint VARCHAR(100) NOT NULL,
+    input TEXT NOT NULL,
+    query TEXT NOT NULL,
+    duration_ms DOUBLE PRECISION NOT NULL,
+    row_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_query_logs_user_id ON query_logs (user_id);
+CREATE INDEX idx_query_logs_created_at ON query_logs (created_at);
@@ -0,0 +1 @@
+ALTER TABLE query_logs ADD COLUMN waf_rule VARCHAR(100);
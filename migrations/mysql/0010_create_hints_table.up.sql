@@ -0,0 +1,11 @@
+CREATE TABLE hints (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    challenge_id VARCHAR(100) NOT NULL,
+    position INT NOT NULL,
+    content TEXT NOT NULL,
+    cost INT NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (challenge_id, position),
+    FOREIGN KEY (challenge_id) REFERENCES challenges(id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+CREATE TABLE solves (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    challenge_id VARCHAR(100) NOT NULL,
+    points INT NOT NULL,
+    solved_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, challenge_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (challenge_id) REFERENCES challenges(id) ON DELETE CASCADE
+);
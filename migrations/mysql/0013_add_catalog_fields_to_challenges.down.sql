@@ -0,0 +1,2 @@
+ALTER TABLE challenges DROP COLUMN endpoint;
+ALTER TABLE challenges DROP COLUMN category;
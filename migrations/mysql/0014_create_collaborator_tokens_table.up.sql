@@ -0,0 +1,6 @@
+CREATE TABLE collaborator_tokens (
+    user_id INT PRIMARY KEY,
+    token VARCHAR(32) UNIQUE NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
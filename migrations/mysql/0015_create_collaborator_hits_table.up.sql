@@ -0,0 +1,13 @@
+CREATE TABLE collaborator_hits (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT,
+    token VARCHAR(255) NOT NULL,
+    protocol VARCHAR(10) NOT NULL,
+    data TEXT NOT NULL,
+    source_ip VARCHAR(45) NOT NULL,
+    raw TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_collaborator_hits_user_id ON collaborator_hits (user_id);
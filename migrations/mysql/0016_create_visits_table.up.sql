@@ -0,0 +1,8 @@
+CREATE TABLE visits (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    path TEXT NOT NULL,
+    user_agent TEXT,
+    forwarded_for TEXT,
+    visitor_id TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,19 @@
+CREATE TABLE wallets (
+    user_id INT PRIMARY KEY,
+    balance NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE wallet_transactions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    order_id INT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_wallet_transactions_user_id ON wallet_transactions (user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE internet_packages (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    price NUMERIC(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
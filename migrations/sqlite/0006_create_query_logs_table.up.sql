@@ -0,0 +1,15 @@
+CREATE TABLE query_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source_ip VARCHAR(45) NOT NULL,
+    endpoint VARCHAR(100) NOT NULL,
+    input TEXT NOT NULL,
+    query TEXT NOT NULL,
+    duration_ms DOUBLE PRECISION NOT NULL,
+    row_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_query_logs_user_id ON query_logs (user_id);
+CREATE INDEX idx_query_logs_created_at ON query_logs (created_at);
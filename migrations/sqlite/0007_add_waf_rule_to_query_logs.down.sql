@@ -0,0 +1 @@
+ALTER TABLE query_logs DROP COLUMN waf_rule;
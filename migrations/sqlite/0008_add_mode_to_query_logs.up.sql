@@ -0,0 +1 @@
+ALTER TABLE query_logs ADD COLUMN mode VARCHAR(20) NOT NULL DEFAULT 'vulnerable';
@@ -0,0 +1,8 @@
+CREATE TABLE visits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL,
+    user_agent TEXT,
+    forwarded_for TEXT,
+    visitor_id TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_internet_packages_category;
+ALTER TABLE internet_packages DROP COLUMN is_active;
+ALTER TABLE internet_packages DROP COLUMN category;
+ALTER TABLE internet_packages DROP COLUMN validity_days;
+ALTER TABLE internet_packages DROP COLUMN quota_gb;
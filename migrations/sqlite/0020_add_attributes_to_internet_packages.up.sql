@@ -0,0 +1,5 @@
+ALTER TABLE internet_packages ADD COLUMN quota_gb NUMERIC(8, 2) NOT NULL DEFAULT 0;
+ALTER TABLE internet_packages ADD COLUMN validity_days INT NOT NULL DEFAULT 30;
+ALTER TABLE internet_packages ADD COLUMN category VARCHAR(50) NOT NULL DEFAULT 'regular';
+ALTER TABLE internet_packages ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+CREATE INDEX idx_internet_packages_category ON internet_packages (category);
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Challenge struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Points      int       `json:"points"`
+	Endpoint    string    `json:"endpoint"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ChallengeSummary struct {
+	Challenge
+	HintCount int  `json:"hint_count"`
+	Solved    bool `json:"solved"`
+}
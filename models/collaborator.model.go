@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type CollaboratorHit struct {
+	ID        int       `json:"id"`
+	UserID    *int      `json:"user_id"`
+	Token     string    `json:"token"`
+	Protocol  string    `json:"protocol"`
+	Data      string    `json:"data"`
+	SourceIP  string    `json:"source_ip"`
+	Raw       string    `json:"raw"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Flag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Value     string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FlagSubmission struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	FlagID     *int      `json:"flag_id"`
+	Submission string    `json:"submission"`
+	IsCorrect  bool      `json:"is_correct"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type Hint struct {
+	ID          int       `json:"id"`
+	ChallengeID string    `json:"challenge_id"`
+	Position    int       `json:"position" binding:"required"`
+	Content     string    `json:"content" binding:"required"`
+	Cost        int       `json:"cost"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
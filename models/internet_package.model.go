@@ -3,10 +3,30 @@ package models
 import "time"
 
 type InternetPackage struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description" binding:"required"`
-	Price       float64   `json:"price" binding:"required"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           string  `json:"id"`
+	Name         string  `json:"name" binding:"required"`
+	Description  string  `json:"description" binding:"required"`
+	Price        float64 `json:"price" binding:"required"`
+	QuotaGB      float64 `json:"quota_gb" binding:"gte=0"`
+	ValidityDays int     `json:"validity_days" binding:"gte=0"`
+	Category     string  `json:"category" binding:"max=50"`
+	// IsActive is a pointer so that a request leaving it out is told apart
+	// from one that turns the package off. Packages are active by default.
+	IsActive  *bool     `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type InternetPackageFilter struct {
+	Search      string
+	Category    string
+	Active      string
+	MinQuota    string
+	MaxQuota    string
+	MinValidity string
+	MaxValidity string
+	Sort        string
+	Order       string
+	Limit       string
+	Offset      string
 }
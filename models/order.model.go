@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Order struct {
+	ID     int `json:"id"`
+	UserID int `json:"user_id"`
+	// PackageID is nil once the package has been deleted. The name and
+	// price are kept as they were at the time of purchase.
+	PackageID   *string   `json:"package_id"`
+	PackageName string    `json:"package_name"`
+	Price       float64   `json:"price"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type OrderFilter struct {
+	UserID    int
+	PackageID string
+	Status    string
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	PerPage   int
+}
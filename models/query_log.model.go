@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type QueryLog struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	SourceIP   string    `json:"source_ip"`
+	Endpoint   string    `json:"endpoint"`
+	Mode       string    `json:"mode"`
+	Input      string    `json:"input"`
+	Query      string    `json:"query"`
+	DurationMs float64   `json:"duration_ms"`
+	RowCount   int       `json:"row_count"`
+	Error      *string   `json:"error"`
+	WAFRule    *string   `json:"waf_rule"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type QueryLogFilter struct {
+	UserID   int
+	SourceIP string
+	Endpoint string
+	Mode     string
+	Search   string
+	HasError *bool
+	Blocked  *bool
+	Page     int
+	PerPage  int
+}
@@ -0,0 +1,13 @@
+package models
+
+type PurchaseReportItem struct {
+	Customer string  `json:"customer"`
+	Package  string  `json:"package"`
+	Price    float64 `json:"price"`
+}
+
+type PurchaseReport struct {
+	Customer string               `json:"customer"`
+	Items    []PurchaseReportItem `json:"items"`
+	Total    float64              `json:"total"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Solve struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	ChallengeID string    `json:"challenge_id"`
+	Points      int       `json:"points"`
+	SolvedAt    time.Time `json:"solved_at"`
+}
+
+type ScoreboardEntry struct {
+	Rank        int        `json:"rank"`
+	UserID      int        `json:"user_id"`
+	Name        string     `json:"name"`
+	Score       int        `json:"score"`
+	Solves      int        `json:"solves"`
+	LastSolveAt *time.Time `json:"last_solve_at"`
+}
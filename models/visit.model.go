@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Visit struct {
+	ID           int       `json:"id"`
+	Path         string    `json:"path"`
+	UserAgent    string    `json:"user_agent"`
+	ForwardedFor string    `json:"forwarded_for"`
+	VisitorID    string    `json:"visitor_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
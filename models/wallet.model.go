@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Wallet struct {
+	UserID    int       `json:"user_id"`
+	Balance   float64   `json:"balance"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WalletTransaction is one ledger entry. Top-ups are positive and purchases
+// negative, so the entries of a user add up to their balance.
+type WalletTransaction struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Amount    float64   `json:"amount"`
+	Kind      string    `json:"kind"`
+	OrderID   *int      `json:"order_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterAdminRoutes(r *gin.RouterGroup) {
+	admin := r.Group("/admin", middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
+	{
+		admin.GET("/query-log", controllers.GetQueryLogs)
+
+		admin.GET("/orders", controllers.GetAllOrders)
+
+		admin.GET("/modes", controllers.GetLabModes)
+		admin.PUT("/modes/:path", controllers.SetLabMode)
+
+		admin.GET("/scenarios", controllers.GetLabScenarios)
+		admin.PUT("/scenarios", controllers.SetLabScenarios)
+
+		admin.POST("/reset", controllers.ResetLab)
+
+		admin.GET("/throttle", controllers.GetThrottleUsage)
+
+		admin.GET("/challenges/:id/hints", controllers.GetHints)
+		admin.POST("/challenges/:id/hints", controllers.CreateHint)
+		admin.PUT("/challenges/:id/hints/:hint_id", controllers.UpdateHint)
+		admin.DELETE("/challenges/:id/hints/:hint_id", controllers.DeleteHint)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterChallengeRoutes(r *gin.RouterGroup) {
+	challenges := r.Group("/challenges")
+	{
+		challenges.GET("", middlewares.AuthMiddleware(), controllers.GetChallenges)
+		challenges.GET("/:id/hints", middlewares.AuthMiddleware(), controllers.GetUnlockedHints)
+		challenges.GET("/:id/hints/next", middlewares.AuthMiddleware(), controllers.UnlockNextHint)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterCollaboratorRoutes(r *gin.RouterGroup) {
+	collaborator := r.Group("/collaborator")
+	{
+		collaborator.GET("", middlewares.AuthMiddleware(), controllers.GetCollaborator)
+		collaborator.GET("/hits", middlewares.AuthMiddleware(), controllers.GetCollaboratorHits)
+	}
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterFlagRoutes(r *gin.RouterGroup) {
+	flags := r.Group("/flags")
+	{
+		flags.POST("/submit", middlewares.AuthMiddleware(), controllers.SubmitFlag)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/noverdy/sqli-demo-lab/controllers"
 	"github.com/noverdy/sqli-demo-lab/middlewares"
+	"github.com/noverdy/sqli-demo-lab/throttle"
 )
 
 func RegisterInternetPackageRoutes(r *gin.RouterGroup) {
@@ -15,6 +16,8 @@ func RegisterInternetPackageRoutes(r *gin.RouterGroup) {
 		packages.PUT("/:id", middlewares.AuthMiddleware(), middlewares.AdminMiddleware(), controllers.UpdateInternetPackage)
 		packages.DELETE("/:id", middlewares.AuthMiddleware(), middlewares.AdminMiddleware(), controllers.DeleteInternetPackage)
 
-		packages.POST("/buy", middlewares.AuthMiddleware(), controllers.BuyInternetPackage)
+		packages.GET("/query-preview", middlewares.AuthMiddleware(), controllers.PreviewPackageExistsQuery)
+		packages.GET("/:id", middlewares.AuthMiddleware(), controllers.GetInternetPackage)
+		packages.POST("/buy", middlewares.AuthMiddleware(), middlewares.ThrottleMiddleware(throttle.Buy), middlewares.WAFMiddleware(), controllers.BuyInternetPackage)
 	}
 }
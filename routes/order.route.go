@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterOrderRoutes(r *gin.RouterGroup) {
+	orders := r.Group("/orders")
+	{
+		orders.GET("/", middlewares.AuthMiddleware(), controllers.GetOrders)
+		orders.GET("/:id", middlewares.AuthMiddleware(), controllers.GetOrder)
+	}
+}
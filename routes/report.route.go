@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterReportRoutes(r *gin.RouterGroup) {
+	reports := r.Group("/reports")
+	{
+		reports.GET("/purchase", middlewares.AuthMiddleware(), controllers.GetPurchaseReport)
+	}
+}
@@ -1,21 +1,54 @@
 package routes
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/mandrigin/gin-spa/spa"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
 )
 
-func SetupRouter() *gin.Engine {
+func SetupRouter() (*gin.Engine, error) {
 	r := gin.Default()
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES must be a comma separated list of IPs or CIDRs: %v", err)
+	}
 	r.Use(setupCORSMiddleware())
 
 	api := r.Group("/api")
+	if lab.TrackingEnabled() {
+		api.Use(middlewares.TrackingMiddleware())
+	}
 	RegisterAuthRoutes(api)
 	RegisterInternetPackageRoutes(api)
+	RegisterFlagRoutes(api)
+	RegisterChallengeRoutes(api)
+	RegisterScoreboardRoutes(api)
+	RegisterReportRoutes(api)
+	RegisterOrderRoutes(api)
+	RegisterWalletRoutes(api)
+	RegisterCollaboratorRoutes(api)
+	RegisterAdminRoutes(api)
 
 	r.Use(spa.Middleware("/", "./frontend/dist"))
 
-	return r
+	return r, nil
+}
+
+// trustedProxies lists the proxies whose X-Forwarded-For is believed by
+// ClientIP. The per-IP throttle and the query log key on ClientIP, so by
+// default no proxy is trusted and a participant cannot pick their own IP.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
 }
 
 func setupCORSMiddleware() gin.HandlerFunc {
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+)
+
+func RegisterScoreboardRoutes(r *gin.RouterGroup) {
+	scoreboard := r.Group("/scoreboard")
+	{
+		scoreboard.GET("", controllers.GetScoreboard)
+		scoreboard.GET("/stream", controllers.StreamScoreboard)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/noverdy/sqli-demo-lab/controllers"
+	"github.com/noverdy/sqli-demo-lab/middlewares"
+)
+
+func RegisterWalletRoutes(r *gin.RouterGroup) {
+	wallet := r.Group("/wallet")
+	{
+		wallet.GET("", middlewares.AuthMiddleware(), controllers.GetWallet)
+		wallet.POST("/topup", middlewares.AuthMiddleware(), controllers.TopUpWallet)
+	}
+}
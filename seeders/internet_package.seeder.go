@@ -6,47 +6,76 @@ import (
 	"github.com/noverdy/sqli-demo-lab/db"
 )
 
-func SeedInternetPackages() {
+func SeedInternetPackages(exec db.Executor) {
 	packages := []struct {
-		Name        string
-		Description string
-		Price       float64
+		Name         string
+		Description  string
+		Price        float64
+		QuotaGB      float64
+		ValidityDays int
+		Category     string
 	}{
 		{
-			Name:        "Super Seru",
-			Description: "Paket Super Seru adalah paket internet yang menawarkan kuota besar dengan kecepatan tinggi, cocok untuk streaming, gaming, dan browsing tanpa batas.",
-			Price:       120000,
+			Name:         "Super Seru",
+			Description:  "Paket Super Seru adalah paket internet yang menawarkan kuota besar dengan kecepatan tinggi, cocok untuk streaming, gaming, dan browsing tanpa batas.",
+			Price:        120000,
+			QuotaGB:      50,
+			ValidityDays: 30,
+			Category:     "regular",
 		},
 		{
-			Name:        "StreaMAX",
-			Description: "Paket StreaMAX dirancang khusus untuk pengguna yang suka streaming video dan musik dengan kualitas HD tanpa buffering.",
-			Price:       200000,
+			Name:         "StreaMAX",
+			Description:  "Paket StreaMAX dirancang khusus untuk pengguna yang suka streaming video dan musik dengan kualitas HD tanpa buffering.",
+			Price:        200000,
+			QuotaGB:      75,
+			ValidityDays: 30,
+			Category:     "streaming",
 		},
 		{
-			Name:        "Internet Sakti",
-			Description: "Paket Internet Sakti memberikan kuota hemat dengan harga terjangkau, ideal untuk pengguna yang membutuhkan internet untuk kebutuhan sehari-hari.",
-			Price:       80000,
+			Name:         "Internet Sakti",
+			Description:  "Paket Internet Sakti memberikan kuota hemat dengan harga terjangkau, ideal untuk pengguna yang membutuhkan internet untuk kebutuhan sehari-hari.",
+			Price:        80000,
+			QuotaGB:      15,
+			ValidityDays: 30,
+			Category:     "regular",
 		},
 		{
-			Name:        "Paket Serbu Sahur",
-			Description: "Paket Serbu Sahur adalah paket internet khusus yang memberikan kuota besar dengan harga hemat, aktif pada jam sahur untuk mendukung aktivitas malam hari.",
-			Price:       50000,
+			Name:         "Paket Serbu Sahur",
+			Description:  "Paket Serbu Sahur adalah paket internet khusus yang memberikan kuota besar dengan harga hemat, aktif pada jam sahur untuk mendukung aktivitas malam hari.",
+			Price:        50000,
+			QuotaGB:      20,
+			ValidityDays: 7,
+			Category:     "night",
 		},
 		{
-			Name:        "Internet OMG!",
-			Description: "Paket Internet OMG! menawarkan kuota besar untuk semua aplikasi favorit Anda, termasuk media sosial, streaming, dan gaming, dengan kecepatan tinggi.",
-			Price:       150000,
+			Name:         "Internet OMG!",
+			Description:  "Paket Internet OMG! menawarkan kuota besar untuk semua aplikasi favorit Anda, termasuk media sosial, streaming, dan gaming, dengan kecepatan tinggi.",
+			Price:        150000,
+			QuotaGB:      40,
+			ValidityDays: 30,
+			Category:     "regular",
 		},
 		{
-			Name:        "Kuota Ketengan",
-			Description: "Kuota Ketengan adalah paket internet fleksibel dengan kuota kecil yang cocok untuk kebutuhan mendadak atau penggunaan singkat.",
-			Price:       25000,
+			Name:         "Kuota Ketengan",
+			Description:  "Kuota Ketengan adalah paket internet fleksibel dengan kuota kecil yang cocok untuk kebutuhan mendadak atau penggunaan singkat.",
+			Price:        25000,
+			QuotaGB:      2,
+			ValidityDays: 1,
+			Category:     "daily",
 		},
 	}
 
 	for _, internetPackage := range packages {
-		query := "INSERT INTO internet_packages (name, description, price) VALUES ($1, $2, $3)"
-		_, err := db.DB.Exec(query, internetPackage.Name, internetPackage.Description, internetPackage.Price)
+		checkQuery := "SELECT COUNT(*) FROM internet_packages WHERE name = $1"
+		var count int
+		exec.QueryRow(checkQuery, internetPackage.Name).Scan(&count)
+		if count > 0 {
+			fillPackageAttributes(exec, internetPackage.Name, internetPackage.QuotaGB, internetPackage.ValidityDays, internetPackage.Category)
+			continue
+		}
+
+		query := "INSERT INTO internet_packages (name, description, price, quota_gb, validity_days, category) VALUES ($1, $2, $3, $4, $5, $6)"
+		_, err := exec.Exec(query, internetPackage.Name, internetPackage.Description, internetPackage.Price, internetPackage.QuotaGB, internetPackage.ValidityDays, internetPackage.Category)
 		if err != nil {
 			log.Printf("Failed to seed internet package %s: %v", internetPackage.Name, err)
 		} else {
@@ -54,3 +83,21 @@ func SeedInternetPackages() {
 		}
 	}
 }
+
+// fillPackageAttributes sets the quota, validity and category of a package
+// seeded before those columns existed. A package whose quota was already set
+// is left alone, so edits made by an admin survive a new seed.
+func fillPackageAttributes(exec db.Executor, name string, quotaGB float64, validityDays int, category string) {
+	query := "UPDATE internet_packages SET quota_gb = $1, validity_days = $2, category = $3 WHERE name = $4 AND quota_gb = 0"
+	result, err := exec.Exec(query, quotaGB, validityDays, category, name)
+	if err != nil {
+		log.Printf("Failed to fill in attributes of internet package %s: %v", name, err)
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		log.Printf("Filled in attributes of internet package: %s", name)
+	} else {
+		log.Printf("Internet package %s already exists", name)
+	}
+}
@@ -1,54 +1,81 @@
 package seeders
 
 import (
+	"crypto/rand"
 	"log"
-	"math/rand"
+	"math/big"
 
 	"github.com/noverdy/sqli-demo-lab/db"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func SeedUsers() {
-	users := []struct {
-		Name     string
-		Email    string
-		Password string
-		IsAdmin  bool
-	}{
-		{"Admin", "admin@myseclab.com", generateRandomString(16), true},
-		{"John Doe", "john.doe@myseclab.com", generateRandomString(16), false},
-		{"Jane Doe", "jane.doe@myseclab.com", "password123", false},
+var seededUsers = []struct {
+	Name    string
+	Email   string
+	IsAdmin bool
+}{
+	{"Admin", "admin@myseclab.com", true},
+	{"John Doe", "john.doe@myseclab.com", false},
+	{"Jane Doe", "jane.doe@myseclab.com", false},
+}
+
+// IsSeededUser reports whether the account with this email is created by
+// SeedUsers rather than registered by a participant.
+func IsSeededUser(email string) bool {
+	for _, user := range seededUsers {
+		if user.Email == email {
+			return true
+		}
 	}
+	return false
+}
 
-	for _, user := range users {
+// SeedUsers creates the seeded accounts that do not exist yet. It returns the
+// password of the admin account, or "" when the admin already existed.
+func SeedUsers(exec db.Executor) string {
+	var adminPassword string
+	for _, user := range seededUsers {
 		checkQuery := "SELECT COUNT(*) FROM users WHERE email = $1"
 		var count int
-		db.DB.QueryRow(checkQuery, user.Email).Scan(&count)
+		exec.QueryRow(checkQuery, user.Email).Scan(&count)
 		if count > 0 {
 			log.Printf("User %s already exists", user.Email)
 			continue
 		}
 
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		password := "password123"
+		if user.Email != "jane.doe@myseclab.com" {
+			password = generateRandomString(16)
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 		if err != nil {
 			log.Fatalf("Failed to hash password for user %s: %v", user.Email, err)
 		}
 
 		query := "INSERT INTO users (name, email, password, is_admin) VALUES ($1, $2, $3, $4)"
-		_, err = db.DB.Exec(query, user.Name, user.Email, string(hashedPassword), user.IsAdmin)
+		_, err = exec.Exec(query, user.Name, user.Email, string(hashedPassword), user.IsAdmin)
 		if err != nil {
 			log.Printf("Failed to seed user %s: %v", user.Email, err)
 		} else {
 			log.Printf("Seeded user: %s", user.Email)
+			if user.IsAdmin {
+				adminPassword = password
+			}
 		}
 	}
+	return adminPassword
 }
 
 func generateRandomString(n int) string {
 	var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 	b := make([]rune, n)
 	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(letterRunes))))
+		if err != nil {
+			log.Fatalf("Failed to generate random string: %v", err)
+		}
+		b[i] = letterRunes[n.Int64()]
 	}
 	return string(b)
 }
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+var ErrChallengeNotFound = errors.New("challenge not found")
+
+func GetChallenges(userID int) ([]models.ChallengeSummary, error) {
+	query := `SELECT c.id, c.title, c.description, c.category, c.points, c.endpoint, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM hints h WHERE h.challenge_id = c.id),
+			EXISTS (SELECT 1 FROM solves s WHERE s.challenge_id = c.id AND s.user_id = $1)
+		FROM challenges c
+		ORDER BY c.category, c.points, c.id`
+	rows, err := db.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var challenges []models.ChallengeSummary = []models.ChallengeSummary{}
+	for rows.Next() {
+		var challenge models.ChallengeSummary
+		if err := rows.Scan(&challenge.ID, &challenge.Title, &challenge.Description, &challenge.Category, &challenge.Points, &challenge.Endpoint, &challenge.CreatedAt, &challenge.UpdatedAt, &challenge.HintCount, &challenge.Solved); err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, challenge)
+	}
+	return challenges, nil
+}
+
+func GetChallengeByID(id string) (models.Challenge, error) {
+	query := "SELECT id, title, description, category, points, endpoint, created_at, updated_at FROM challenges WHERE id = $1"
+	var challenge models.Challenge
+	err := db.DB.QueryRow(query, id).Scan(&challenge.ID, &challenge.Title, &challenge.Description, &challenge.Category, &challenge.Points, &challenge.Endpoint, &challenge.CreatedAt, &challenge.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return challenge, ErrChallengeNotFound
+	}
+	if err != nil {
+		return challenge, err
+	}
+	return challenge, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"log"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+func GetOrCreateCollaboratorToken(userID int) (string, error) {
+	var token string
+	query := "SELECT token FROM collaborator_tokens WHERE user_id = $1"
+	err := db.DB.QueryRow(query, userID).Scan(&token)
+	if err == nil {
+		return token, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token = hex.EncodeToString(b)
+
+	// Another request may have created a token in the meantime, in which
+	// case that one is kept.
+	insertQuery := "INSERT INTO collaborator_tokens (user_id, token) VALUES ($1, $2)" + db.OnConflictDoNothing("user_id")
+	_, err = db.DB.Exec(insertQuery, userID, token)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.DB.QueryRow(query, userID).Scan(&token)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RecordCollaboratorHit stores a hit for the owner of its token. Hits for
+// tokens nobody was handed are dropped, so that the listeners cannot be used
+// to fill the table.
+func RecordCollaboratorHit(hit models.CollaboratorHit) {
+	if hit.Token == "" || len(hit.Token) > 255 {
+		return
+	}
+
+	var userID int
+	err := db.DB.QueryRow("SELECT user_id FROM collaborator_tokens WHERE token = $1", hit.Token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to look up collaborator token: %v", err)
+		return
+	}
+
+	query := `INSERT INTO collaborator_hits (user_id, token, protocol, data, source_ip, raw)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = db.DB.Exec(query, userID, hit.Token, hit.Protocol, hit.Data, hit.SourceIP, hit.Raw)
+	if err != nil {
+		log.Printf("Failed to record collaborator hit: %v", err)
+	}
+}
+
+func GetCollaboratorHits(userID int, page int, perPage int) ([]models.CollaboratorHit, int, error) {
+	var total int
+	countQuery := "SELECT COUNT(*) FROM collaborator_hits WHERE user_id = $1"
+	if err := db.DB.QueryRow(countQuery, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, user_id, token, protocol, data, source_ip, raw, created_at
+		FROM collaborator_hits WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`
+	rows, err := db.DB.Query(query, userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var hits []models.CollaboratorHit = []models.CollaboratorHit{}
+	for rows.Next() {
+		var hit models.CollaboratorHit
+		if err := rows.Scan(&hit.ID, &hit.UserID, &hit.Token, &hit.Protocol, &hit.Data, &hit.SourceIP, &hit.Raw, &hit.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, total, rows.Err()
+}
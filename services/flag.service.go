@@ -0,0 +1,70 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/noverdy/sqli-demo-lab/challenges"
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+// vectorFlags returns the flags a lab query may read, keyed by challenge.
+// Those are the flags of the challenges on its code path, and none when
+// input reached it through more than one vulnerable code path, since any of
+// them could then have been used to read the flag.
+func vectorFlags(codePaths []string) (map[string]string, error) {
+	flags := map[string]string{}
+	if len(codePaths) != 1 {
+		return flags, nil
+	}
+
+	for _, name := range challenges.FlagNames(codePaths[0]) {
+		var value string
+		err := db.DB.QueryRow("SELECT value FROM flags WHERE name = $1", name).Scan(&value)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		flags[name] = value
+	}
+	return flags, nil
+}
+
+// SubmitFlag checks submission against the flag of challengeID only, so a
+// flag read through one challenge cannot solve another.
+func SubmitFlag(userID int, challengeID string, submission string) (models.FlagSubmission, *models.Flag, error) {
+	result := models.FlagSubmission{
+		UserID:     userID,
+		Submission: strings.TrimSpace(submission),
+	}
+
+	var flag models.Flag
+	query := "SELECT id, name, value, created_at FROM flags WHERE name = $1 AND value = $2"
+	err := db.DB.QueryRow(query, challengeID, result.Submission).Scan(&flag.ID, &flag.Name, &flag.Value, &flag.CreatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return result, nil, err
+	}
+	if err == nil {
+		result.FlagID = &flag.ID
+		result.IsCorrect = true
+	}
+
+	insertQuery := "INSERT INTO flag_submissions (user_id, flag_id, submission, is_correct) VALUES ($1, $2, $3, $4)"
+	result.ID, err = db.InsertID(db.DB, insertQuery, result.UserID, result.FlagID, result.Submission, result.IsCorrect)
+	if err != nil {
+		return result, nil, err
+	}
+
+	err = db.DB.QueryRow("SELECT created_at FROM flag_submissions WHERE id = $1", result.ID).Scan(&result.CreatedAt)
+	if err != nil {
+		return result, nil, err
+	}
+
+	if !result.IsCorrect {
+		return result, nil, nil
+	}
+	return result, &flag, nil
+}
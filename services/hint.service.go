@@ -0,0 +1,143 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+var (
+	ErrHintNotFound = errors.New("hint not found")
+	ErrNoMoreHints  = errors.New("all hints for this challenge have been unlocked")
+)
+
+func GetHints(challengeID string) ([]models.Hint, error) {
+	query := "SELECT id, challenge_id, position, content, cost, created_at, updated_at FROM hints WHERE challenge_id = $1 ORDER BY position"
+	return queryHints(query, challengeID)
+}
+
+func GetUnlockedHints(userID int, challengeID string) ([]models.Hint, error) {
+	query := `SELECT h.id, h.challenge_id, h.position, h.content, h.cost, h.created_at, h.updated_at
+		FROM hints h JOIN hint_unlocks u ON u.hint_id = h.id
+		WHERE h.challenge_id = $1 AND u.user_id = $2
+		ORDER BY h.position`
+	return queryHints(query, challengeID, userID)
+}
+
+func queryHints(query string, args ...any) ([]models.Hint, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hints []models.Hint = []models.Hint{}
+	for rows.Next() {
+		var hint models.Hint
+		if err := rows.Scan(&hint.ID, &hint.ChallengeID, &hint.Position, &hint.Content, &hint.Cost, &hint.CreatedAt, &hint.UpdatedAt); err != nil {
+			return nil, err
+		}
+		hints = append(hints, hint)
+	}
+	return hints, nil
+}
+
+func UnlockNextHint(userID int, challengeID string) (models.Hint, int, error) {
+	var hint models.Hint
+	if _, err := GetChallengeByID(challengeID); err != nil {
+		return hint, 0, err
+	}
+
+	query := `SELECT h.id, h.challenge_id, h.position, h.content, h.cost, h.created_at, h.updated_at
+		FROM hints h
+		WHERE h.challenge_id = $1
+		AND NOT EXISTS (SELECT 1 FROM hint_unlocks u WHERE u.hint_id = h.id AND u.user_id = $2)
+		ORDER BY h.position
+		LIMIT 1`
+	err := db.DB.QueryRow(query, challengeID, userID).Scan(&hint.ID, &hint.ChallengeID, &hint.Position, &hint.Content, &hint.Cost, &hint.CreatedAt, &hint.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return hint, 0, ErrNoMoreHints
+	}
+	if err != nil {
+		return hint, 0, err
+	}
+
+	insertQuery := "INSERT INTO hint_unlocks (user_id, hint_id) VALUES ($1, $2)" + db.OnConflictDoNothing("user_id", "hint_id")
+	_, err = db.DB.Exec(insertQuery, userID, hint.ID)
+	if err != nil {
+		return hint, 0, err
+	}
+
+	var remaining int
+	remainingQuery := "SELECT COUNT(*) FROM hints WHERE challenge_id = $1 AND position > $2"
+	err = db.DB.QueryRow(remainingQuery, challengeID, hint.Position).Scan(&remaining)
+	if err != nil {
+		return hint, 0, err
+	}
+
+	return hint, remaining, nil
+}
+
+func GetHintPenalty(userID int, challengeID string) (int, error) {
+	query := `SELECT COALESCE(SUM(h.cost), 0)
+		FROM hints h JOIN hint_unlocks u ON u.hint_id = h.id
+		WHERE h.challenge_id = $1 AND u.user_id = $2`
+	var penalty int
+	err := db.DB.QueryRow(query, challengeID, userID).Scan(&penalty)
+	return penalty, err
+}
+
+func CreateHint(hint models.Hint) (models.Hint, error) {
+	if _, err := GetChallengeByID(hint.ChallengeID); err != nil {
+		return hint, err
+	}
+
+	query := "INSERT INTO hints (challenge_id, position, content, cost) VALUES ($1, $2, $3, $4)"
+	id, err := db.InsertID(db.DB, query, hint.ChallengeID, hint.Position, hint.Content, hint.Cost)
+	if err != nil {
+		return hint, err
+	}
+	hint.ID = id
+
+	err = db.DB.QueryRow("SELECT created_at, updated_at FROM hints WHERE id = $1", hint.ID).Scan(&hint.CreatedAt, &hint.UpdatedAt)
+	if err != nil {
+		return hint, err
+	}
+	return hint, nil
+}
+
+func UpdateHint(id int, hint models.Hint) error {
+	query := "UPDATE hints SET position = $1, content = $2, cost = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4 AND challenge_id = $5"
+	result, err := db.DB.Exec(query, hint.Position, hint.Content, hint.Cost, id, hint.ChallengeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrHintNotFound
+	}
+	return nil
+}
+
+func DeleteHint(challengeID string, id int) error {
+	query := "DELETE FROM hints WHERE id = $1 AND challenge_id = $2"
+	result, err := db.DB.Exec(query, id, challengeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrHintNotFound
+	}
+	return nil
+}
@@ -1,70 +1,429 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/lab"
 	"github.com/noverdy/sqli-demo-lab/models"
 )
 
-func CheckInternetPackageExists(packageID string) (bool, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM internet_packages WHERE id = '%s'", packageID)
-	fmt.Println(">", query)
+func BuildPackageExistsQuery(mode lab.Mode, packageID string) (string, []any) {
+	if mode == lab.ModeSecure {
+		return "SELECT COUNT(*) FROM internet_packages WHERE " + db.TextCast("id") + " = $1", []any{packageID}
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM internet_packages WHERE id = '%s'", packageID), nil
+}
+
+func CheckInternetPackageExists(ctx context.Context, req LabRequest, packageID string) (bool, error) {
+	mode := lab.CurrentMode(lab.PathBuyInternetPackage)
+	query, args := BuildPackageExistsQuery(mode, packageID)
+	stacked := mode == lab.ModeVulnerable && lab.StackedQueriesEnabled()
+	if mode == lab.ModeVulnerable {
+		req.CodePaths = []string{lab.PathBuyInternetPackage}
+	}
+
+	run := runLabQuery
+	if stacked {
+		run = runStackedLabQuery
+	}
+
 	var count int
-	err := db.DB.QueryRow(query).Scan(&count)
-	if err != nil {
+	err := run(ctx, req, mode, packageID, query, func(ctx context.Context, conn *sql.Conn) (int, error) {
+		if stacked {
+			results, err := db.QueryStacked(ctx, conn, query)
+			if err != nil {
+				return 0, err
+			}
+			if len(results) == 0 || len(results[0]) == 0 || len(results[0][0]) == 0 {
+				return 0, nil
+			}
+			count, err = strconv.Atoi(results[0][0][0])
+			return len(results[0]), err
+		}
+
+		args, err := db.SingleStatementArgs(query, args)
+		if err != nil {
+			return 0, err
+		}
+		err = conn.QueryRowContext(ctx, query, args...).Scan(&count)
 		if err == sql.ErrNoRows {
-			return false, nil
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
 		}
+		return 1, nil
+	})
+
+	if mode == lab.ModeVulnerable {
+		CheckAdminEscalation(req.UserID, req.Schema)
+	}
+
+	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
 
-func GetAllInternetPackages(searchQuery string) ([]models.InternetPackage, error) {
-	var rows *sql.Rows
-	var err error
+var ErrInvalidSort = errors.New("invalid sort column or order")
 
-	if searchQuery != "" {
-		query := "SELECT id, name, description, price, created_at, updated_at FROM internet_packages WHERE name ILIKE $1"
-		rows, err = db.DB.Query(query, "%"+searchQuery+"%")
-	} else {
-		query := "SELECT id, name, description, price, created_at, updated_at FROM internet_packages"
-		rows, err = db.DB.Query(query)
+var sortableColumns = map[string]string{
+	"name":          "name",
+	"price":         "price",
+	"quota_gb":      "quota_gb",
+	"validity_days": "validity_days",
+	"created_at":    "created_at",
+	"updated_at":    "updated_at",
+}
+
+var ErrInvalidFilter = errors.New("invalid package filter")
+
+// buildInternetPackageConditions turns the search and attribute filters into
+// a WHERE clause. The values are always bound, in both modes, since the lab
+// exercises on this endpoint are the sort and pagination clauses.
+func buildInternetPackageConditions(filter models.InternetPackageFilter) (string, []any, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Search != "" {
+		addCondition("name "+db.ILike()+" $%d", "%"+filter.Search+"%")
+	}
+	if filter.Category != "" {
+		addCondition("category = $%d", strings.ToLower(filter.Category))
+	}
+	if filter.Active != "" {
+		active, err := strconv.ParseBool(filter.Active)
+		if err != nil {
+			return "", nil, ErrInvalidFilter
+		}
+		addCondition("is_active = $%d", active)
+	}
+
+	ranges := []struct {
+		value  string
+		format string
+	}{
+		{filter.MinQuota, "quota_gb >= $%d"},
+		{filter.MaxQuota, "quota_gb <= $%d"},
+		{filter.MinValidity, "validity_days >= $%d"},
+		{filter.MaxValidity, "validity_days <= $%d"},
+	}
+	for _, r := range ranges {
+		if r.value == "" {
+			continue
+		}
+		bound, err := strconv.ParseFloat(r.value, 64)
+		if err != nil || bound < 0 {
+			return "", nil, ErrInvalidFilter
+		}
+		addCondition(r.format, bound)
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func BuildInternetPackagesQuery(mode lab.Mode, filter models.InternetPackageFilter) (string, []any, error) {
+	where, args, err := buildInternetPackageConditions(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	query := "SELECT id, name, description, price, quota_gb, validity_days, category, is_active, created_at, updated_at FROM internet_packages" + where
+
+	if filter.Sort == "" && filter.Order == "" {
+		return query, args, nil
+	}
+
+	sort, order := filter.Sort, filter.Order
+	if sort == "" {
+		sort = "name"
+	}
+	if order == "" {
+		order = "ASC"
+	}
+
+	if mode == lab.ModeSecure {
+		column, ok := sortableColumns[strings.ToLower(sort)]
+		direction := strings.ToUpper(order)
+		if !ok || (direction != "ASC" && direction != "DESC") {
+			return "", nil, ErrInvalidSort
+		}
+		return query + " ORDER BY " + column + " " + direction, args, nil
+	}
+
+	// Identifiers cannot be bound as parameters, so the vulnerable variant
+	// formats them straight into the query.
+	return query + fmt.Sprintf(" ORDER BY %s %s", sort, order), args, nil
+}
+
+var ErrInvalidPagination = errors.New("invalid limit or offset")
+
+const maxListLimit = 100
+
+func BuildPaginationClause(mode lab.Mode, filter models.InternetPackageFilter, args []any) (string, []any, error) {
+	clause := ""
+
+	if mode == lab.ModeSecure {
+		if filter.Limit != "" {
+			limit, err := strconv.Atoi(filter.Limit)
+			if err != nil || limit < 1 || limit > maxListLimit {
+				return "", nil, ErrInvalidPagination
+			}
+			args = append(args, limit)
+			clause += fmt.Sprintf(" LIMIT $%d", len(args))
+		}
+		if filter.Offset != "" {
+			offset, err := strconv.Atoi(filter.Offset)
+			if err != nil || offset < 0 {
+				return "", nil, ErrInvalidPagination
+			}
+			args = append(args, offset)
+			clause += fmt.Sprintf(" OFFSET $%d", len(args))
+		}
+		return clause, args, nil
+	}
+
+	// The vulnerable variant trusts the values to be numbers, so there is no
+	// quote to break out of and anything Postgres accepts as an expression
+	// runs.
+	if filter.Limit != "" {
+		clause += " LIMIT " + filter.Limit
+	}
+	if filter.Offset != "" {
+		clause += " OFFSET " + filter.Offset
+	}
+	return clause, args, nil
+}
+
+func GetAllInternetPackages(ctx context.Context, req LabRequest, filter models.InternetPackageFilter) ([]models.InternetPackage, error) {
+	sortMode := lab.CurrentMode(lab.PathListInternetPackages)
+	query, args, err := BuildInternetPackagesQuery(sortMode, filter)
+	if err != nil {
+		return nil, err
 	}
 
+	pageMode := lab.CurrentMode(lab.PathPaginateInternetPackages)
+	pagination, args, err := BuildPaginationClause(pageMode, filter, args)
+	if err != nil {
+		return nil, err
+	}
+	query += pagination
+
+	vulnerableSort := sortMode == lab.ModeVulnerable && (filter.Sort != "" || filter.Order != "")
+	vulnerablePagination := pageMode == lab.ModeVulnerable && (filter.Limit != "" || filter.Offset != "")
+	if vulnerableSort {
+		req.CodePaths = append(req.CodePaths, lab.PathListInternetPackages)
+	}
+	if vulnerablePagination {
+		req.CodePaths = append(req.CodePaths, lab.PathPaginateInternetPackages)
+	}
+	if vulnerableSort || vulnerablePagination {
+		var packages []models.InternetPackage
+		input := fmt.Sprintf("sort=%s order=%s limit=%s offset=%s", filter.Sort, filter.Order, filter.Limit, filter.Offset)
+		err := runLabQuery(ctx, req, lab.ModeVulnerable, input, query, func(ctx context.Context, conn *sql.Conn) (int, error) {
+			rows, err := conn.QueryContext(ctx, query, args...)
+			if err != nil {
+				return 0, err
+			}
+			defer rows.Close()
+
+			packages, err = scanInternetPackages(rows)
+			return len(packages), err
+		})
+		return packages, err
+	}
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanInternetPackages(rows)
+}
+
+func CountInternetPackages(ctx context.Context, filter models.InternetPackageFilter) (int, error) {
+	where, args, err := buildInternetPackageConditions(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	err = db.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM internet_packages"+where, args...).Scan(&total)
+	return total, err
+}
+
+func scanInternetPackages(rows *sql.Rows) ([]models.InternetPackage, error) {
 	var packages []models.InternetPackage = []models.InternetPackage{}
 	for rows.Next() {
 		var pkg models.InternetPackage
-		if err := rows.Scan(&pkg.ID, &pkg.Name, &pkg.Description, &pkg.Price, &pkg.CreatedAt, &pkg.UpdatedAt); err != nil {
+		if err := rows.Scan(&pkg.ID, &pkg.Name, &pkg.Description, &pkg.Price, &pkg.QuotaGB, &pkg.ValidityDays, &pkg.Category, &pkg.IsActive, &pkg.CreatedAt, &pkg.UpdatedAt); err != nil {
 			return nil, err
 		}
 		packages = append(packages, pkg)
 	}
 
-	return packages, nil
+	return packages, rows.Err()
+}
+
+var ErrInternetPackageNotFound = errors.New("internet package not found")
+
+func BuildInternetPackageQuery(mode lab.Mode, id string) (string, []any) {
+	if mode == lab.ModeSecure {
+		return "SELECT id, name, description, price, quota_gb, validity_days, category, is_active, created_at, updated_at FROM internet_packages WHERE " + db.TextCast("id") + " = $1", []any{id}
+	}
+	return fmt.Sprintf("SELECT id, name, description, price, quota_gb, validity_days, category, is_active, created_at, updated_at FROM internet_packages WHERE id = '%s'", id), nil
+}
+
+// GetInternetPackage returns the package as a column name to value map. The
+// vulnerable variant reflects whatever columns the first returned row has,
+// which is what makes UNION payloads visible in the response.
+func GetInternetPackage(ctx context.Context, req LabRequest, id string) (map[string]any, error) {
+	mode := lab.CurrentMode(lab.PathGetInternetPackage)
+	query, args := BuildInternetPackageQuery(mode, id)
+
+	if mode == lab.ModeSecure {
+		rows, err := db.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		packages, err := scanInternetPackages(rows)
+		if err != nil {
+			return nil, err
+		}
+		if len(packages) == 0 {
+			return nil, ErrInternetPackageNotFound
+		}
+
+		pkg := packages[0]
+		return map[string]any{
+			"id":            pkg.ID,
+			"name":          pkg.Name,
+			"description":   pkg.Description,
+			"price":         pkg.Price,
+			"quota_gb":      pkg.QuotaGB,
+			"validity_days": pkg.ValidityDays,
+			"category":      pkg.Category,
+			"is_active":     pkg.IsActive,
+			"created_at":    pkg.CreatedAt,
+			"updated_at":    pkg.UpdatedAt,
+		}, nil
+	}
+
+	var result map[string]any
+	req.CodePaths = []string{lab.PathGetInternetPackage}
+	err := runLabQuery(ctx, req, mode, id, query, func(ctx context.Context, conn *sql.Conn) (int, error) {
+		rows, err := conn.QueryContext(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		if err != nil {
+			return 0, err
+		}
+
+		rowCount := 0
+		for rows.Next() {
+			rowCount++
+			if result != nil {
+				continue
+			}
+
+			values := make([]any, len(columns))
+			pointers := make([]any, len(columns))
+			for i := range values {
+				pointers[i] = &values[i]
+			}
+			if err := rows.Scan(pointers...); err != nil {
+				return rowCount, err
+			}
+
+			result = make(map[string]any, len(columns))
+			for i, column := range columns {
+				if value, ok := values[i].([]byte); ok {
+					result[column] = string(value)
+				} else {
+					result[column] = values[i]
+				}
+			}
+		}
+		return rowCount, rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrInternetPackageNotFound
+	}
+
+	return result, nil
+}
+
+// DefaultPackageCategory is used when a package is saved without a category.
+const DefaultPackageCategory = "regular"
+
+func normalizeInternetPackage(pkg models.InternetPackage) models.InternetPackage {
+	pkg.Category = strings.ToLower(strings.TrimSpace(pkg.Category))
+	if pkg.Category == "" {
+		pkg.Category = DefaultPackageCategory
+	}
+	if pkg.ValidityDays == 0 {
+		pkg.ValidityDays = 30
+	}
+	if pkg.IsActive == nil {
+		active := true
+		pkg.IsActive = &active
+	}
+	return pkg
 }
 
 func CreateInternetPackage(pkg models.InternetPackage) (models.InternetPackage, error) {
-	query := "INSERT INTO internet_packages (name, description, price) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at"
-	err := db.DB.QueryRow(query, pkg.Name, pkg.Description, pkg.Price).Scan(&pkg.ID, &pkg.CreatedAt, &pkg.UpdatedAt)
+	pkg = normalizeInternetPackage(pkg)
+
+	// The id is generated here because MySQL cannot return a UUID default.
+	id, err := db.NewUUID()
 	if err != nil {
 		return pkg, err
 	}
+	pkg.ID = id
 
+	query := "INSERT INTO internet_packages (id, name, description, price, quota_gb, validity_days, category, is_active) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err = db.DB.Exec(query, pkg.ID, pkg.Name, pkg.Description, pkg.Price, pkg.QuotaGB, pkg.ValidityDays, pkg.Category, *pkg.IsActive)
+	if err != nil {
+		return pkg, err
+	}
+
+	err = db.DB.QueryRow("SELECT created_at, updated_at FROM internet_packages WHERE id = $1", pkg.ID).Scan(&pkg.CreatedAt, &pkg.UpdatedAt)
+	if err != nil {
+		return pkg, err
+	}
+
+	syncParticipantPackages()
 	return pkg, nil
 }
 
 func UpdateInternetPackage(id string, pkg models.InternetPackage) error {
-	query := "UPDATE internet_packages SET name = $1, description = $2, price = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4"
-	result, err := db.DB.Exec(query, pkg.Name, pkg.Description, pkg.Price, id)
+	pkg = normalizeInternetPackage(pkg)
+
+	query := "UPDATE internet_packages SET name = $1, description = $2, price = $3, quota_gb = $4, validity_days = $5, category = $6, is_active = $7, updated_at = CURRENT_TIMESTAMP WHERE id = $8"
+	result, err := db.DB.Exec(query, pkg.Name, pkg.Description, pkg.Price, pkg.QuotaGB, pkg.ValidityDays, pkg.Category, *pkg.IsActive, id)
 	if err != nil {
 		return err
 	}
@@ -77,6 +436,7 @@ func UpdateInternetPackage(id string, pkg models.InternetPackage) error {
 		return errors.New("internet package not found")
 	}
 
+	syncParticipantPackages()
 	return nil
 }
 
@@ -95,5 +455,6 @@ func DeleteInternetPackage(id string) error {
 		return errors.New("internet package not found")
 	}
 
+	syncParticipantPackages()
 	return nil
 }
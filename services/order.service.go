@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+const OrderStatusCompleted = "completed"
+
+var ErrOrderNotFound = errors.New("order not found")
+
+var ErrInternetPackageInactive = errors.New("internet package is no longer sold")
+
+// CreateOrder records a purchase of packageID from the shared catalog and
+// pays for it from the user's wallet, failing with ErrInsufficientFunds when
+// the balance does not cover the price and ErrInternetPackageInactive when
+// the package was taken off sale. The package name and price are
+// copied into the order, so later edits to the package do not change the
+// purchase history.
+func CreateOrder(ctx context.Context, userID int, packageID string) (models.Order, error) {
+	order := models.Order{UserID: userID, Status: OrderStatusCompleted}
+
+	// The package is looked up before the transaction starts, so that its
+	// first statement is a write. SQLite cannot upgrade a read transaction
+	// while another one is writing.
+	var id string
+	var active bool
+	query := "SELECT id, name, price, is_active FROM internet_packages WHERE " + db.TextCast("id") + " = $1"
+	err := db.DB.QueryRowContext(ctx, query, packageID).Scan(&id, &order.PackageName, &order.Price, &active)
+	if err == sql.ErrNoRows {
+		return order, ErrInternetPackageNotFound
+	}
+	if err != nil {
+		return order, err
+	}
+	if !active {
+		return order, ErrInternetPackageInactive
+	}
+	order.PackageID = &id
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return order, err
+	}
+	defer tx.Rollback()
+
+	insertQuery := "INSERT INTO orders (user_id, package_id, package_name, price, status) VALUES ($1, $2, $3, $4, $5)"
+	order.ID, err = db.InsertID(tx, insertQuery, order.UserID, id, order.PackageName, order.Price, order.Status)
+	if err != nil {
+		return order, err
+	}
+
+	if err := debitWallet(tx, userID, order.Price, order.ID); err != nil {
+		return order, err
+	}
+
+	err = tx.QueryRow("SELECT created_at FROM orders WHERE id = $1", order.ID).Scan(&order.CreatedAt)
+	if err != nil {
+		return order, err
+	}
+
+	return order, tx.Commit()
+}
+
+func GetOrders(filter models.OrderFilter) ([]models.Order, int, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.PackageID != "" {
+		addCondition(db.TextCast("package_id")+" = $%d", filter.PackageID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", filter.From.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", filter.To.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM orders" + where
+	if err := db.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf(
+		"SELECT id, user_id, package_id, package_name, price, status, created_at FROM orders%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		where, len(args)-1, len(args),
+	)
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	return orders, total, err
+}
+
+// GetOrder returns the order only if it belongs to userID, so that other
+// users' ids look the same as missing ones.
+func GetOrder(userID int, id int) (models.Order, error) {
+	query := "SELECT id, user_id, package_id, package_name, price, status, created_at FROM orders WHERE id = $1 AND user_id = $2"
+	rows, err := db.DB.Query(query, id, userID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(orders) == 0 {
+		return models.Order{}, ErrOrderNotFound
+	}
+	return orders[0], nil
+}
+
+func scanOrders(rows *sql.Rows) ([]models.Order, error) {
+	var orders []models.Order = []models.Order{}
+	for rows.Next() {
+		var order models.Order
+		if err := rows.Scan(&order.ID, &order.UserID, &order.PackageID, &order.PackageName, &order.Price, &order.Status, &order.CreatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
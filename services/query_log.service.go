@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+type LabRequest struct {
+	UserID int
+	// SourceIP is gin's ClientIP, which only follows X-Forwarded-For when it
+	// comes from one of the TRUSTED_PROXIES.
+	SourceIP string
+	Endpoint string
+	Schema   string
+	// CodePaths are the vulnerable code paths whose input reached the query.
+	// It can only read the flags of their challenges, see vectorFlags.
+	CodePaths []string
+}
+
+func runLabQuery(ctx context.Context, req LabRequest, mode lab.Mode, input string, query string, fn func(ctx context.Context, conn *sql.Conn) (int, error)) error {
+	return runStackedLabQuery(ctx, req, mode, input, query, func(ctx context.Context, conn *sql.Conn) (int, error) {
+		if err := db.CheckSingleStatement(query); err != nil {
+			return 0, err
+		}
+		return fn(ctx, conn)
+	})
+}
+
+// runStackedLabQuery is runLabQuery for queries that are allowed to carry
+// more than one statement.
+func runStackedLabQuery(ctx context.Context, req LabRequest, mode lab.Mode, input string, query string, fn func(ctx context.Context, conn *sql.Conn) (int, error)) error {
+	flags, err := vectorFlags(req.CodePaths)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := db.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var rowCount int
+	start := time.Now()
+	err = db.WithSearchPath(ctx, req.Schema, func(conn *sql.Conn) error {
+		return db.WithFlags(ctx, conn, flags, func() error {
+			var err error
+			rowCount, err = fn(ctx, conn)
+			return err
+		})
+	})
+
+	entry := models.QueryLog{
+		UserID:     req.UserID,
+		SourceIP:   req.SourceIP,
+		Endpoint:   req.Endpoint,
+		Mode:       string(mode),
+		Input:      input,
+		Query:      query,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		RowCount:   rowCount,
+	}
+	if err != nil {
+		message := err.Error()
+		entry.Error = &message
+	}
+	if logErr := CreateQueryLog(entry); logErr != nil {
+		log.Printf("Failed to record query log: %v", logErr)
+	}
+
+	return err
+}
+
+func CreateQueryLog(entry models.QueryLog) error {
+	if entry.Mode == "" {
+		entry.Mode = string(lab.ModeVulnerable)
+	}
+
+	query := "INSERT INTO query_logs (user_id, source_ip, endpoint, mode, input, query, duration_ms, row_count, error, waf_rule) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	_, err := db.DB.Exec(query, entry.UserID, entry.SourceIP, entry.Endpoint, entry.Mode, entry.Input, entry.Query, entry.DurationMs, entry.RowCount, entry.Error, entry.WAFRule)
+	return err
+}
+
+func GetQueryLogs(filter models.QueryLogFilter) ([]models.QueryLog, int, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+	if filter.SourceIP != "" {
+		addCondition("source_ip = $%d", filter.SourceIP)
+	}
+	if filter.Endpoint != "" {
+		addCondition("endpoint = $%d", filter.Endpoint)
+	}
+	if filter.Mode != "" {
+		addCondition("mode = $%d", filter.Mode)
+	}
+	if filter.Search != "" {
+		addCondition("input "+db.ILike()+" $%d", "%"+filter.Search+"%")
+	}
+	if filter.Blocked != nil {
+		if *filter.Blocked {
+			conditions = append(conditions, "waf_rule IS NOT NULL")
+		} else {
+			conditions = append(conditions, "waf_rule IS NULL")
+		}
+	}
+	if filter.HasError != nil {
+		if *filter.HasError {
+			conditions = append(conditions, "error IS NOT NULL")
+		} else {
+			conditions = append(conditions, "error IS NULL")
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM query_logs" + where
+	if err := db.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf(
+		"SELECT id, user_id, source_ip, endpoint, mode, input, query, duration_ms, row_count, error, waf_rule, created_at FROM query_logs%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		where, len(args)-1, len(args),
+	)
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var logs []models.QueryLog = []models.QueryLog{}
+	for rows.Next() {
+		var entry models.QueryLog
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.SourceIP, &entry.Endpoint, &entry.Mode, &entry.Input, &entry.Query, &entry.DurationMs, &entry.RowCount, &entry.Error, &entry.WAFRule, &entry.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		logs = append(logs, entry)
+	}
+
+	return logs, total, nil
+}
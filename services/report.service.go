@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+// The customer name was stored safely at registration. The vulnerable
+// variant trusts it because it comes from our own database.
+func BuildPurchaseReportQuery(mode lab.Mode, customer string) (string, []any) {
+	if mode == lab.ModeSecure {
+		return "SELECT " + db.TextCast("$1") + " AS customer, name, price FROM internet_packages ORDER BY price, name", []any{customer}
+	}
+	return fmt.Sprintf("SELECT '%s' AS customer, name, price FROM internet_packages ORDER BY price, name", customer), nil
+}
+
+func GeneratePurchaseReport(ctx context.Context, req LabRequest, user models.User) (models.PurchaseReport, error) {
+	report := models.PurchaseReport{
+		Customer: user.Name,
+		Items:    []models.PurchaseReportItem{},
+	}
+
+	mode := lab.CurrentMode(lab.PathPurchaseReport)
+	query, args := BuildPurchaseReportQuery(mode, user.Name)
+	if mode == lab.ModeVulnerable {
+		req.CodePaths = []string{lab.PathPurchaseReport}
+	}
+	err := runLabQuery(ctx, req, mode, user.Name, query, func(ctx context.Context, conn *sql.Conn) (int, error) {
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var item models.PurchaseReportItem
+			if err := rows.Scan(&item.Customer, &item.Package, &item.Price); err != nil {
+				return len(report.Items), err
+			}
+			report.Items = append(report.Items, item)
+			report.Total += item.Price
+		}
+		return len(report.Items), rows.Err()
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/noverdy/sqli-demo-lab/challenges"
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/seeders"
+)
+
+const resetLockID = 7_000_001
+
+// participantSnapshot holds what survives a reset: the registered accounts,
+// their solves and the hints they unlocked. Hints are kept by challenge and
+// position, since their ids change when the catalog is synced again.
+type participantSnapshot struct {
+	users       []keptUser
+	solves      []keptSolve
+	hintUnlocks []keptHintUnlock
+}
+
+type keptUser struct {
+	ID        int
+	Name      string
+	Email     string
+	Password  string
+	CreatedAt time.Time
+}
+
+type keptSolve struct {
+	UserID      int
+	ChallengeID string
+	Points      int
+	SolvedAt    time.Time
+}
+
+type keptHintUnlock struct {
+	UserID      int
+	ChallengeID string
+	Position    int
+	CreatedAt   time.Time
+}
+
+// ResetLab rebuilds the lab from the migrations and seeders. Participant
+// accounts and the scoreboard are kept, everything else is recreated. It
+// returns the newly generated admin password.
+func ResetLab() (string, error) {
+	snapshot, err := snapshotParticipants()
+	if err != nil {
+		return "", fmt.Errorf("failed to keep participant accounts: %v", err)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var schemas []string
+	if db.SupportsSchemas() {
+		schemas, err = dropSchemas(tx)
+	} else {
+		err = dropTables(tx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = db.ApplyMigrations(tx, db.MigrationsDir())
+	if err != nil {
+		return "", err
+	}
+
+	adminPassword := seeders.SeedUsers(tx)
+	seeders.SeedInternetPackages(tx)
+
+	err = challenges.Sync(tx)
+	if err != nil {
+		return "", err
+	}
+
+	userIDs, err := restoreParticipants(tx, snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+
+	for _, userID := range userIDs {
+		if err := ProvisionUserSchema(userID); err != nil {
+			log.Printf("Failed to provision schema for user %d: %v", userID, err)
+		}
+	}
+
+	log.Printf("Lab reset completed, %d participant schemas dropped, %d accounts kept", len(schemas), len(userIDs))
+	return adminPassword, nil
+}
+
+func snapshotParticipants() (participantSnapshot, error) {
+	var snapshot participantSnapshot
+
+	rows, err := db.DB.Query("SELECT id, name, email, password, created_at FROM users ORDER BY id")
+	if err != nil {
+		return snapshot, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user keptUser
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt); err != nil {
+			return snapshot, err
+		}
+		if !seeders.IsSeededUser(user.Email) {
+			snapshot.users = append(snapshot.users, user)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return snapshot, err
+	}
+
+	rows, err = db.DB.Query("SELECT user_id, challenge_id, points, solved_at FROM solves")
+	if err != nil {
+		return snapshot, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var solve keptSolve
+		if err := rows.Scan(&solve.UserID, &solve.ChallengeID, &solve.Points, &solve.SolvedAt); err != nil {
+			return snapshot, err
+		}
+		snapshot.solves = append(snapshot.solves, solve)
+	}
+	if err := rows.Err(); err != nil {
+		return snapshot, err
+	}
+
+	query := `SELECT u.user_id, h.challenge_id, h.position, u.created_at
+		FROM hint_unlocks u JOIN hints h ON h.id = u.hint_id`
+	rows, err = db.DB.Query(query)
+	if err != nil {
+		return snapshot, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var unlock keptHintUnlock
+		if err := rows.Scan(&unlock.UserID, &unlock.ChallengeID, &unlock.Position, &unlock.CreatedAt); err != nil {
+			return snapshot, err
+		}
+		snapshot.hintUnlocks = append(snapshot.hintUnlocks, unlock)
+	}
+	return snapshot, rows.Err()
+}
+
+// restoreParticipants puts the snapshot back into the freshly seeded tables
+// and returns the ids of the restored accounts. Accounts come back without
+// admin rights, since a participant may have granted them to themselves.
+// Solves and unlocks of challenges that left the catalog are dropped.
+func restoreParticipants(tx *sql.Tx, snapshot participantSnapshot) ([]int, error) {
+	kept := make(map[int]bool)
+	var userIDs []int
+	for _, user := range snapshot.users {
+		query := "INSERT INTO users (id, name, email, password, is_admin, created_at) VALUES ($1, $2, $3, $4, FALSE, $5)" + db.OnConflictDoNothing("id")
+		result, err := tx.Exec(query, user.ID, user.Name, user.Email, user.Password, user.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore user %s: %v", user.Email, err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			log.Printf("User %s was not kept, its id is taken by a seeded account", user.Email)
+			continue
+		}
+		kept[user.ID] = true
+		userIDs = append(userIDs, user.ID)
+	}
+
+	if db.CurrentDialect() == db.DialectPostgres {
+		_, err := tx.Exec("SELECT setval(pg_get_serial_sequence('users', 'id'), MAX(id)) FROM users")
+		if err != nil {
+			return nil, fmt.Errorf("failed to update users sequence: %v", err)
+		}
+	}
+
+	for _, solve := range snapshot.solves {
+		if !kept[solve.UserID] {
+			continue
+		}
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM challenges WHERE id = $1", solve.ChallengeID).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			continue
+		}
+
+		query := "INSERT INTO solves (user_id, challenge_id, points, solved_at) VALUES ($1, $2, $3, $4)"
+		if _, err := tx.Exec(query, solve.UserID, solve.ChallengeID, solve.Points, solve.SolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to restore solve of user %d: %v", solve.UserID, err)
+		}
+	}
+
+	for _, unlock := range snapshot.hintUnlocks {
+		if !kept[unlock.UserID] {
+			continue
+		}
+		var hintID int
+		err := tx.QueryRow("SELECT id FROM hints WHERE challenge_id = $1 AND position = $2", unlock.ChallengeID, unlock.Position).Scan(&hintID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		query := "INSERT INTO hint_unlocks (user_id, hint_id, created_at) VALUES ($1, $2, $3)"
+		if _, err := tx.Exec(query, unlock.UserID, hintID, unlock.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to restore hint unlock of user %d: %v", unlock.UserID, err)
+		}
+	}
+
+	return userIDs, nil
+}
+
+func dropSchemas(tx *sql.Tx) ([]string, error) {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", resetLockID)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas, err := listParticipantSchemas(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schema := range append(schemas, "public") {
+		_, err = tx.Exec("DROP SCHEMA IF EXISTS " + pgx.Identifier{schema}.Sanitize() + " CASCADE")
+		if err != nil {
+			return nil, fmt.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	}
+
+	_, err = tx.Exec("CREATE SCHEMA public")
+	if err != nil {
+		return nil, fmt.Errorf("failed to recreate schema public: %v", err)
+	}
+
+	return schemas, nil
+}
+
+// dropTables empties a database without schemas. Tables are dropped in
+// rounds, since a table cannot go while another one still references it.
+func dropTables(tx *sql.Tx) error {
+	for {
+		rows, err := tx.Query(listTablesQuery())
+		if err != nil {
+			return err
+		}
+
+		var tables []string
+		for rows.Next() {
+			var table string
+			if err := rows.Scan(&table); err != nil {
+				rows.Close()
+				return err
+			}
+			tables = append(tables, table)
+		}
+		rows.Close()
+		if len(tables) == 0 {
+			return nil
+		}
+
+		var lastErr error
+		dropped := 0
+		for _, table := range tables {
+			if _, err := tx.Exec("DROP TABLE IF EXISTS " + db.QuoteIdentifier(table)); err != nil {
+				lastErr = err
+				continue
+			}
+			dropped++
+		}
+		if dropped == 0 {
+			return fmt.Errorf("failed to drop tables: %v", lastErr)
+		}
+	}
+}
+
+func listTablesQuery() string {
+	if db.CurrentDialect() == db.DialectMySQL {
+		return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()"
+	}
+	return "SELECT name FROM sqlite_master WHERE type = 'table' AND substr(name, 1, 7) <> 'sqlite_'"
+}
+
+func ScheduleLabReset(interval time.Duration) {
+	log.Printf("Lab will be reset every %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			log.Println("Running scheduled lab reset...")
+			adminPassword, err := ResetLab()
+			if err != nil {
+				log.Printf("Scheduled lab reset failed: %v", err)
+				continue
+			}
+			log.Printf("New admin password: %s", adminPassword)
+		}
+	}()
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/seeders"
+)
+
+const EscalateToAdminChallengeID = "escalate-to-admin"
+
+func UserSchemaName(userID int) string {
+	return fmt.Sprintf("lab_user_%d", userID)
+}
+
+func ProvisionUserSchema(userID int) error {
+	if !db.SupportsSchemas() {
+		return nil
+	}
+	schema := UserSchemaName(userID)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT pg_advisory_xact_lock($1)", userID)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)"
+	err = tx.QueryRow(query, schema).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := createUserSchema(tx, schema); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// The participant gets a row in their own copy of users, which is what
+	// the admin escalation scenario expects them to promote. A student who
+	// broke their users table should still be able to log in, so failures
+	// are only logged.
+	if err := copyParticipantIntoSchema(userID, schema); err != nil {
+		log.Printf("Failed to copy user %d into schema %s: %v", userID, schema, err)
+	}
+
+	return nil
+}
+
+func createUserSchema(tx *sql.Tx, schema string) error {
+	identifier := pgx.Identifier{schema}.Sanitize()
+	_, err := tx.Exec("CREATE SCHEMA " + identifier)
+	if err != nil {
+		return fmt.Errorf("failed to create schema %s: %v", schema, err)
+	}
+
+	_, err = tx.Exec("SET LOCAL search_path TO " + identifier)
+	if err != nil {
+		return err
+	}
+
+	err = db.ApplyMigrations(tx, db.MigrationsDir())
+	if err != nil {
+		return err
+	}
+
+	seeders.SeedUsers(tx)
+	if err := mirrorInternetPackages(tx, schema); err != nil {
+		return fmt.Errorf("failed to copy internet packages into schema %s: %v", schema, err)
+	}
+
+	if db.RestrictedRoleEnabled() {
+		grants, err := lab.CurrentRoleGrants()
+		if err != nil {
+			return err
+		}
+		if err := ensureParticipantRole(tx, schema); err != nil {
+			return fmt.Errorf("failed to create role %s: %v", schema, err)
+		}
+		if err := grantParticipantRole(tx, schema, grants); err != nil {
+			return fmt.Errorf("failed to grant %s on schema %s: %v", schema, schema, err)
+		}
+	}
+
+	return nil
+}
+
+// SyncRestrictedRole gives every participant schema its login role and
+// applies the grants of the current level to it, replacing those of a
+// previous level. The restricted role itself no longer logs in and only
+// holds what all participant roles share.
+func SyncRestrictedRole() error {
+	grants, err := lab.CurrentRoleGrants()
+	if err != nil {
+		return err
+	}
+	if !db.RestrictedRoleEnabled() {
+		return nil
+	}
+
+	role := pgx.Identifier{db.RestrictedRole}.Sanitize()
+	if _, err := db.DB.Exec("ALTER ROLE " + role + " WITH NOLOGIN PASSWORD NULL"); err != nil {
+		return fmt.Errorf("failed to set up role %s, are the migrations applied? %v", db.RestrictedRole, err)
+	}
+
+	statement := "REVOKE pg_execute_server_program FROM " + role
+	if grants.ServerPrograms {
+		statement = "GRANT pg_execute_server_program TO " + role
+	}
+	if _, err := db.DB.Exec(statement); err != nil {
+		return err
+	}
+
+	schemas, err := listParticipantSchemas(db.DB)
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		if err := ensureParticipantRole(db.DB, schema); err != nil {
+			log.Printf("Failed to set up role %s: %v", schema, err)
+			continue
+		}
+		if err := grantParticipantRole(db.DB, schema, grants); err != nil {
+			log.Printf("Failed to grant %s on schema %s: %v", schema, schema, err)
+		}
+	}
+
+	return nil
+}
+
+// ensureParticipantRole creates the login role the lab queries of schema
+// run as. It is named after the schema and inherits the privileges of the
+// restricted role, but it is not a member of any other participant role, so
+// a stacked SET ROLE cannot reach another participant's schema either.
+func ensureParticipantRole(exec db.Executor, schema string) error {
+	role := pgx.Identifier{schema}.Sanitize()
+
+	var exists bool
+	err := exec.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", schema).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := exec.Exec("CREATE ROLE " + role + " NOSUPERUSER NOCREATEDB NOCREATEROLE NOREPLICATION")
+		if err != nil {
+			return err
+		}
+	}
+
+	password := "'" + strings.ReplaceAll(db.ParticipantRolePassword(schema), "'", "''") + "'"
+	statements := []string{
+		"ALTER ROLE " + role + " WITH LOGIN PASSWORD " + password,
+		"GRANT " + pgx.Identifier{db.RestrictedRole}.Sanitize() + " TO " + role,
+	}
+	for _, statement := range statements {
+		if _, err := exec.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grantParticipantRole gives the role of schema the grants of the level on
+// that schema only.
+func grantParticipantRole(exec db.Executor, schema string, grants lab.Grants) error {
+	restricted := pgx.Identifier{db.RestrictedRole}.Sanitize()
+	role := pgx.Identifier{schema}.Sanitize()
+	identifier := pgx.Identifier{schema}.Sanitize()
+
+	statements := []string{
+		// Every participant role is a member of the restricted role, so it
+		// must not hold anything on a participant schema. Migration 0017
+		// grants it usage, and earlier versions granted it tables as well.
+		"REVOKE ALL ON SCHEMA " + identifier + " FROM " + restricted,
+		"REVOKE ALL ON ALL TABLES IN SCHEMA " + identifier + " FROM " + restricted,
+		"REVOKE ALL ON ALL SEQUENCES IN SCHEMA " + identifier + " FROM " + restricted,
+		"GRANT USAGE ON SCHEMA " + identifier + " TO " + role,
+		"REVOKE ALL ON ALL TABLES IN SCHEMA " + identifier + " FROM " + role,
+		"GRANT USAGE ON ALL SEQUENCES IN SCHEMA " + identifier + " TO " + role,
+	}
+	for _, statement := range statements {
+		if _, err := exec.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	// Column grants of a previous level survive the revokes above.
+	rows, err := exec.Query("SELECT grantee, table_name, column_name, privilege_type FROM information_schema.column_privileges WHERE grantee IN ($1, $2) AND table_schema = $2", db.RestrictedRole, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	statements = nil
+	for rows.Next() {
+		var grantee, table, column, privilege string
+		if err := rows.Scan(&grantee, &table, &column, &privilege); err != nil {
+			return err
+		}
+		statements = append(statements, fmt.Sprintf("REVOKE %s (%s) ON %s FROM %s", privilege, pgx.Identifier{column}.Sanitize(), pgx.Identifier{schema, table}.Sanitize(), pgx.Identifier{grantee}.Sanitize()))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for table, privileges := range grants.Tables {
+		target := "ALL TABLES IN SCHEMA " + identifier
+		if table != lab.AllTables {
+			target = pgx.Identifier{schema, table}.Sanitize()
+		}
+		statements = append(statements, "GRANT "+privileges+" ON "+target+" TO "+role)
+	}
+
+	for _, statement := range statements {
+		if _, err := exec.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labTable names table inside schema, or the shared table when the
+// dialect has no schemas and every participant works on the same database.
+func labTable(schema, table string) string {
+	if !db.SupportsSchemas() {
+		return db.QuoteIdentifier(table)
+	}
+	return db.QuoteIdentifier(schema, table)
+}
+
+func copyParticipantIntoSchema(userID int, schema string) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (name, email, password, is_admin) SELECT name, email, password, FALSE FROM public.users WHERE id = $1 ON CONFLICT (email) DO NOTHING",
+		pgx.Identifier{schema, "users"}.Sanitize(),
+	)
+	_, err := db.DB.Exec(query, userID)
+	return err
+}
+
+// CheckAdminEscalation credits the escalate-to-admin challenge once the
+// participant's row in their own schema has been promoted. Without schemas
+// that row is the real account, so there is nothing to check.
+func CheckAdminEscalation(userID int, schema string) {
+	if !db.SupportsSchemas() {
+		return
+	}
+
+	query := fmt.Sprintf(
+		"SELECT u.is_admin FROM %s u WHERE u.email = (SELECT email FROM %s WHERE id = $1)",
+		labTable(schema, "users"), labTable("public", "users"),
+	)
+
+	var isAdmin sql.NullBool
+	if err := db.DB.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return
+	}
+	if !isAdmin.Bool {
+		return
+	}
+
+	_, isNewSolve, err := RecordSolve(userID, EscalateToAdminChallengeID)
+	if err != nil {
+		if !errors.Is(err, ErrChallengeNotFound) {
+			log.Printf("Failed to record admin escalation of user %d: %v", userID, err)
+		}
+		return
+	}
+	if isNewSolve {
+		log.Printf("User %d escalated to admin in schema %s", userID, schema)
+	}
+}
+
+func listParticipantSchemas(exec db.Executor) ([]string, error) {
+	if !db.SupportsSchemas() {
+		return nil, nil
+	}
+
+	rows, err := exec.Query("SELECT schema_name FROM information_schema.schemata WHERE schema_name LIKE 'lab\\_user\\_%'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, rows.Err()
+}
+
+// MigrateParticipantSchemas applies migrations added since each participant
+// schema was provisioned, since ProvisionUserSchema only migrates the schemas
+// it creates. The packages of every schema are then copied from public again.
+func MigrateParticipantSchemas() error {
+	schemas, err := listParticipantSchemas(db.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range schemas {
+		applied, err := migrateParticipantSchema(schema)
+		if err != nil {
+			return fmt.Errorf("failed to migrate schema %s: %v", schema, err)
+		}
+		if applied > 0 {
+			log.Printf("Applied %d migrations to schema %s", applied, schema)
+		}
+	}
+	return nil
+}
+
+func migrateParticipantSchema(schema string) (int, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SET LOCAL search_path TO " + pgx.Identifier{schema}.Sanitize())
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := db.ApplyPendingMigrations(tx, db.MigrationsDir())
+	if err != nil {
+		return 0, err
+	}
+	if err := mirrorInternetPackages(tx, schema); err != nil {
+		return 0, err
+	}
+
+	return applied, tx.Commit()
+}
+
+// packageColumns are the internet_packages columns copied into participant
+// schemas.
+var packageColumns = []string{"id", "name", "description", "price", "quota_gb", "validity_days", "category", "is_active", "created_at", "updated_at"}
+
+// mirrorInternetPackages makes the packages of schema a copy of the catalog
+// in public, ids included. The secure code paths and the admin read public
+// while the vulnerable ones read the participant's copy, so both must agree
+// on every id. Packages a participant added or changed are reverted.
+func mirrorInternetPackages(exec db.Executor, schema string) error {
+	table := pgx.Identifier{schema, "internet_packages"}.Sanitize()
+	columns := strings.Join(packageColumns, ", ")
+
+	statements := []string{
+		"DELETE FROM " + table + " WHERE id NOT IN (SELECT id FROM public.internet_packages)",
+		"INSERT INTO " + table + " (" + columns + ") SELECT " + columns + " FROM public.internet_packages" + db.OnConflictUpdate([]string{"id"}, packageColumns[1:]...),
+	}
+	for _, statement := range statements {
+		if _, err := exec.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncParticipantPackages mirrors the catalog into every participant schema
+// after an admin changed it. Failures are only logged, the change itself
+// has already been made.
+func syncParticipantPackages() {
+	schemas, err := listParticipantSchemas(db.DB)
+	if err != nil {
+		log.Printf("Failed to list participant schemas: %v", err)
+		return
+	}
+
+	for _, schema := range schemas {
+		if err := mirrorInternetPackages(db.DB, schema); err != nil {
+			log.Printf("Failed to copy internet packages into schema %s: %v", schema, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+var scoreboardSubscribers = struct {
+	sync.Mutex
+	channels map[chan struct{}]struct{}
+}{channels: make(map[chan struct{}]struct{})}
+
+// RecordSolve awards the challenge to the user minus the cost of the hints
+// they unlocked. The returned bool is false when it was already solved.
+func RecordSolve(userID int, challengeID string) (models.Solve, bool, error) {
+	solve := models.Solve{UserID: userID, ChallengeID: challengeID}
+
+	challenge, err := GetChallengeByID(challengeID)
+	if err != nil {
+		return solve, false, err
+	}
+
+	penalty, err := GetHintPenalty(userID, challengeID)
+	if err != nil {
+		return solve, false, err
+	}
+	solve.Points = max(challenge.Points-penalty, 0)
+
+	query := "INSERT INTO solves (user_id, challenge_id, points) VALUES ($1, $2, $3)" + db.OnConflictDoNothing("user_id", "challenge_id")
+	solve.ID, err = db.InsertID(db.DB, query, solve.UserID, solve.ChallengeID, solve.Points)
+	if err == sql.ErrNoRows {
+		return solve, false, nil
+	}
+	if err != nil {
+		return solve, false, err
+	}
+
+	err = db.DB.QueryRow("SELECT solved_at FROM solves WHERE id = $1", solve.ID).Scan(&solve.SolvedAt)
+	if err != nil {
+		return solve, false, err
+	}
+
+	notifyScoreboardSubscribers()
+	return solve, true, nil
+}
+
+func GetScoreboard() ([]models.ScoreboardEntry, error) {
+	query := `SELECT u.id, u.name, COALESCE(SUM(s.points), 0) AS score, COUNT(s.id), MAX(s.solved_at) AS last_solve_at
+		FROM users u LEFT JOIN solves s ON s.user_id = u.id
+		WHERE u.is_admin = FALSE
+		GROUP BY u.id, u.name
+		ORDER BY score DESC, MAX(s.solved_at) IS NULL, MAX(s.solved_at) ASC, u.id ASC`
+	rows, err := db.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.ScoreboardEntry = []models.ScoreboardEntry{}
+	for rows.Next() {
+		var entry models.ScoreboardEntry
+		var lastSolveAt db.NullTime
+		if err := rows.Scan(&entry.UserID, &entry.Name, &entry.Score, &entry.Solves, &lastSolveAt); err != nil {
+			return nil, err
+		}
+		if lastSolveAt.Valid {
+			entry.LastSolveAt = &lastSolveAt.Time
+		}
+		entry.Rank = len(entries) + 1
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func SubscribeScoreboard() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	scoreboardSubscribers.Lock()
+	scoreboardSubscribers.channels[ch] = struct{}{}
+	scoreboardSubscribers.Unlock()
+
+	unsubscribe := func() {
+		scoreboardSubscribers.Lock()
+		delete(scoreboardSubscribers.channels, ch)
+		scoreboardSubscribers.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+func notifyScoreboardSubscribers() {
+	scoreboardSubscribers.Lock()
+	defer scoreboardSubscribers.Unlock()
+
+	for ch := range scoreboardSubscribers.channels {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
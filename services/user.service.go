@@ -9,11 +9,12 @@ import (
 )
 
 func CreateUser(user models.User) (models.User, error) {
-	query := "INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id"
-	err := db.DB.QueryRow(query, user.Name, user.Email, user.Password).Scan(&user.ID)
+	query := "INSERT INTO users (name, email, password) VALUES ($1, $2, $3)"
+	id, err := db.InsertID(db.DB, query, user.Name, user.Email, user.Password)
 	if err != nil {
 		return user, err
 	}
+	user.ID = id
 	return user, nil
 }
 
@@ -63,8 +64,14 @@ func GetUserByEmail(email string) (models.User, error) {
 }
 
 func UpdateUser(id int, updatedUser models.User) (models.User, error) {
-	query := "UPDATE users SET name = $1, email = $2 WHERE id = $3 RETURNING id, name, email"
-	err := db.DB.QueryRow(query, updatedUser.Name, updatedUser.Email, id).Scan(&updatedUser.ID, &updatedUser.Name, &updatedUser.Email)
+	query := "UPDATE users SET name = $1, email = $2 WHERE id = $3"
+	_, err := db.DB.Exec(query, updatedUser.Name, updatedUser.Email, id)
+	if err != nil {
+		return updatedUser, err
+	}
+
+	selectQuery := "SELECT id, name, email FROM users WHERE id = $1"
+	err = db.DB.QueryRow(selectQuery, id).Scan(&updatedUser.ID, &updatedUser.Name, &updatedUser.Email)
 	if err == sql.ErrNoRows {
 		return updatedUser, errors.New("user not found")
 	}
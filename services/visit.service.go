@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/noverdy/sqli-demo-lab/lab"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+// BuildVisitQuery formats every source whose code path is vulnerable into
+// the SQL and binds the others, so each source can be taught on its own.
+func BuildVisitQuery(visit models.Visit) (string, []any) {
+	args := []any{visit.Path}
+	values := []string{"$1"}
+
+	sources := []struct {
+		path  string
+		value string
+	}{
+		{lab.PathVisitUserAgent, visit.UserAgent},
+		{lab.PathVisitForwardedFor, visit.ForwardedFor},
+		{lab.PathVisitVisitorID, visit.VisitorID},
+	}
+	for _, source := range sources {
+		if lab.CurrentMode(source.path) == lab.ModeSecure {
+			args = append(args, source.value)
+			values = append(values, fmt.Sprintf("$%d", len(args)))
+		} else {
+			values = append(values, fmt.Sprintf("'%s'", source.value))
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO visits (path, user_agent, forwarded_for, visitor_id) VALUES (%s)", strings.Join(values, ", "))
+	return query, args
+}
+
+func RecordVisit(ctx context.Context, req LabRequest, visit models.Visit) error {
+	mode := lab.ModeSecure
+	sources := map[string]string{
+		lab.PathVisitUserAgent:    visit.UserAgent,
+		lab.PathVisitForwardedFor: visit.ForwardedFor,
+		lab.PathVisitVisitorID:    visit.VisitorID,
+	}
+	for path, value := range sources {
+		if lab.CurrentMode(path) != lab.ModeVulnerable {
+			continue
+		}
+		mode = lab.ModeVulnerable
+		// Every source is formatted into a string literal, which can only be
+		// left through a quote, or a backslash on MySQL. A source without
+		// either cannot have changed the statement.
+		if strings.ContainsAny(value, `'\`) {
+			req.CodePaths = append(req.CodePaths, path)
+		}
+	}
+
+	query, args := BuildVisitQuery(visit)
+	input := fmt.Sprintf("User-Agent: %s\nX-Forwarded-For: %s\nvisitor_id: %s", visit.UserAgent, visit.ForwardedFor, visit.VisitorID)
+	return runLabQuery(ctx, req, mode, input, query, func(ctx context.Context, conn *sql.Conn) (int, error) {
+		result, err := conn.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, _ := result.RowsAffected()
+		return int(rowsAffected), nil
+	})
+}
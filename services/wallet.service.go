@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/noverdy/sqli-demo-lab/db"
+	"github.com/noverdy/sqli-demo-lab/models"
+)
+
+const (
+	WalletTransactionTopUp    = "topup"
+	WalletTransactionPurchase = "purchase"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient wallet balance")
+
+// TopUpWallet credits amount to the user's wallet, creating the wallet on
+// the first top-up, and records it in the ledger.
+func TopUpWallet(ctx context.Context, userID int, amount float64) (models.Wallet, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO wallets (user_id) VALUES ($1)"+db.OnConflictDoNothing("user_id"), userID)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	_, err = tx.Exec("UPDATE wallets SET balance = balance + $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2", amount, userID)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	_, err = tx.Exec("INSERT INTO wallet_transactions (user_id, amount, kind) VALUES ($1, $2, $3)", userID, amount, WalletTransactionTopUp)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	wallet, err := getWallet(tx, userID)
+	if err != nil {
+		return wallet, err
+	}
+	return wallet, tx.Commit()
+}
+
+// debitWallet takes amount from the user's wallet within tx. The balance
+// check and the debit are one statement, so two concurrent purchases cannot
+// both spend the same money.
+func debitWallet(tx *sql.Tx, userID int, amount float64, orderID int) error {
+	result, err := tx.Exec("UPDATE wallets SET balance = balance - $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2 AND balance >= $1", amount, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientFunds
+	}
+
+	_, err = tx.Exec("INSERT INTO wallet_transactions (user_id, amount, kind, order_id) VALUES ($1, $2, $3, $4)", userID, -amount, WalletTransactionPurchase, orderID)
+	return err
+}
+
+// GetWallet returns the user's wallet, with a zero balance if they never
+// topped up.
+func GetWallet(userID int) (models.Wallet, error) {
+	return getWallet(db.DB, userID)
+}
+
+func getWallet(exec db.Executor, userID int) (models.Wallet, error) {
+	wallet := models.Wallet{UserID: userID}
+	err := exec.QueryRow("SELECT balance, updated_at FROM wallets WHERE user_id = $1", userID).Scan(&wallet.Balance, &wallet.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return wallet, nil
+	}
+	return wallet, err
+}
+
+func GetWalletTransactions(userID int, page int, perPage int) ([]models.WalletTransaction, int, error) {
+	var total int
+	err := db.DB.QueryRow("SELECT COUNT(*) FROM wallet_transactions WHERE user_id = $1", userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, user_id, amount, kind, order_id, created_at FROM wallet_transactions WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3"
+	rows, err := db.DB.Query(query, userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var transactions []models.WalletTransaction = []models.WalletTransaction{}
+	for rows.Next() {
+		var transaction models.WalletTransaction
+		if err := rows.Scan(&transaction.ID, &transaction.UserID, &transaction.Amount, &transaction.Kind, &transaction.OrderID, &transaction.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, total, rows.Err()
+}
@@ -0,0 +1,244 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// PerUser and PerIP cap the requests in flight for one user and one
+	// client IP. Zero means no cap.
+	PerUser int
+	PerIP   int
+	// QueueTimeout is how long a request over the cap waits for a free slot
+	// before it is rejected. Zero rejects it straight away.
+	QueueTimeout time.Duration
+}
+
+// ErrLimitExceeded is returned by Acquire when no slot became free in time.
+var ErrLimitExceeded = errors.New("too many concurrent requests")
+
+var config Config
+
+func InitializeConfig() error {
+	var err error
+	if config.PerUser, err = parseLimit("THROTTLE_PER_USER", 4); err != nil {
+		return err
+	}
+	if config.PerIP, err = parseLimit("THROTTLE_PER_IP", 8); err != nil {
+		return err
+	}
+
+	value := os.Getenv("THROTTLE_QUEUE_TIMEOUT")
+	if value == "" {
+		return nil
+	}
+	config.QueueTimeout, err = time.ParseDuration(value)
+	if err != nil || config.QueueTimeout < 0 {
+		return fmt.Errorf("THROTTLE_QUEUE_TIMEOUT must be a duration such as 5s, or 0 to reject straight away, got %q", value)
+	}
+	return nil
+}
+
+func parseLimit(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("%s must be a number of requests, or 0 for no limit, got %q", name, value)
+	}
+	return limit, nil
+}
+
+func CurrentConfig() Config {
+	return config
+}
+
+type counter struct {
+	inFlight int
+	waiting  int
+}
+
+// Limiter counts the requests in flight per user and per IP. A request takes
+// a slot on both counters at once, so it never holds one while waiting for
+// the other.
+type Limiter struct {
+	mu       sync.Mutex
+	users    map[int]*counter
+	ips      map[string]*counter
+	rejected int
+	// released is closed and replaced whenever a slot frees up, which wakes
+	// every waiting request to try again.
+	released chan struct{}
+}
+
+// Buy guards the buy endpoint, whose time-based payloads hold a database
+// connection for as long as they sleep.
+var Buy = NewLimiter()
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		users:    map[int]*counter{},
+		ips:      map[string]*counter{},
+		released: make(chan struct{}),
+	}
+}
+
+// Slot is a taken place on a Limiter. Release is safe to call more than once.
+type Slot struct {
+	once    sync.Once
+	release func()
+}
+
+func (s *Slot) Release() {
+	s.once.Do(s.release)
+}
+
+// Acquire takes a slot for userID and ip, waiting up to the queue timeout
+// for one to free up. It returns ErrLimitExceeded when none did, or the
+// context error when ctx ends first.
+func (l *Limiter) Acquire(ctx context.Context, userID int, ip string) (*Slot, error) {
+	var deadline <-chan time.Time
+	if config.QueueTimeout > 0 {
+		timer := time.NewTimer(config.QueueTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	l.mu.Lock()
+	user, client := l.counters(userID, ip)
+	user.waiting++
+	client.waiting++
+	defer func() {
+		user.waiting--
+		client.waiting--
+		l.prune(userID, ip)
+		l.mu.Unlock()
+	}()
+
+	for {
+		if l.available(user.inFlight, config.PerUser) && l.available(client.inFlight, config.PerIP) {
+			user.inFlight++
+			client.inFlight++
+			return &Slot{release: func() { l.release(userID, ip) }}, nil
+		}
+		if deadline == nil {
+			l.rejected++
+			return nil, ErrLimitExceeded
+		}
+
+		released := l.released
+		l.mu.Unlock()
+		select {
+		case <-released:
+			l.mu.Lock()
+		case <-deadline:
+			l.mu.Lock()
+			l.rejected++
+			return nil, ErrLimitExceeded
+		case <-ctx.Done():
+			l.mu.Lock()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *Limiter) available(inFlight int, limit int) bool {
+	return limit == 0 || inFlight < limit
+}
+
+func (l *Limiter) counters(userID int, ip string) (*counter, *counter) {
+	user, ok := l.users[userID]
+	if !ok {
+		user = &counter{}
+		l.users[userID] = user
+	}
+	client, ok := l.ips[ip]
+	if !ok {
+		client = &counter{}
+		l.ips[ip] = client
+	}
+	return user, client
+}
+
+// prune drops idle counters, so that spoofed or one-off IPs do not pile up.
+func (l *Limiter) prune(userID int, ip string) {
+	if user := l.users[userID]; user.inFlight == 0 && user.waiting == 0 {
+		delete(l.users, userID)
+	}
+	if client := l.ips[ip]; client.inFlight == 0 && client.waiting == 0 {
+		delete(l.ips, ip)
+	}
+}
+
+func (l *Limiter) release(userID int, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.users[userID].inFlight--
+	l.ips[ip].inFlight--
+	l.prune(userID, ip)
+
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+type UserUsage struct {
+	UserID   int `json:"user_id"`
+	InFlight int `json:"in_flight"`
+	Waiting  int `json:"waiting"`
+}
+
+type IPUsage struct {
+	IP       string `json:"ip"`
+	InFlight int    `json:"in_flight"`
+	Waiting  int    `json:"waiting"`
+}
+
+type Usage struct {
+	Users []UserUsage `json:"users"`
+	IPs   []IPUsage   `json:"ips"`
+	// Rejected counts the requests turned away since the server started.
+	Rejected int `json:"rejected"`
+}
+
+// Usage returns a snapshot of the busiest users and IPs first.
+func (l *Limiter) Usage() Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := Usage{
+		Users:    make([]UserUsage, 0, len(l.users)),
+		IPs:      make([]IPUsage, 0, len(l.ips)),
+		Rejected: l.rejected,
+	}
+	for userID, c := range l.users {
+		usage.Users = append(usage.Users, UserUsage{UserID: userID, InFlight: c.inFlight, Waiting: c.waiting})
+	}
+	for ip, c := range l.ips {
+		usage.IPs = append(usage.IPs, IPUsage{IP: ip, InFlight: c.inFlight, Waiting: c.waiting})
+	}
+
+	sort.Slice(usage.Users, func(i, j int) bool {
+		if usage.Users[i].InFlight != usage.Users[j].InFlight {
+			return usage.Users[i].InFlight > usage.Users[j].InFlight
+		}
+		return usage.Users[i].UserID < usage.Users[j].UserID
+	})
+	sort.Slice(usage.IPs, func(i, j int) bool {
+		if usage.IPs[i].InFlight != usage.IPs[j].InFlight {
+			return usage.IPs[i].InFlight > usage.IPs[j].InFlight
+		}
+		return usage.IPs[i].IP < usage.IPs[j].IP
+	})
+	return usage
+}
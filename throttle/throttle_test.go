@@ -0,0 +1,276 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func withConfig(t *testing.T, c Config) {
+	t.Helper()
+	previous := config
+	config = c
+	t.Cleanup(func() { config = previous })
+}
+
+func TestAcquireLimits(t *testing.T) {
+	type request struct {
+		userID int
+		ip     string
+		err    error
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		requests []request
+	}{
+		{
+			name:   "no limits",
+			config: Config{},
+			requests: []request{
+				{1, "10.0.0.1", nil},
+				{1, "10.0.0.1", nil},
+				{1, "10.0.0.1", nil},
+			},
+		},
+		{
+			name:   "per user",
+			config: Config{PerUser: 2},
+			requests: []request{
+				{1, "10.0.0.1", nil},
+				{1, "10.0.0.2", nil},
+				{1, "10.0.0.3", ErrLimitExceeded},
+				{2, "10.0.0.1", nil},
+			},
+		},
+		{
+			name:   "per ip",
+			config: Config{PerIP: 2},
+			requests: []request{
+				{1, "10.0.0.1", nil},
+				{2, "10.0.0.1", nil},
+				{3, "10.0.0.1", ErrLimitExceeded},
+				{3, "10.0.0.2", nil},
+			},
+		},
+		{
+			name:   "both",
+			config: Config{PerUser: 1, PerIP: 2},
+			requests: []request{
+				{1, "10.0.0.1", nil},
+				{1, "10.0.0.2", ErrLimitExceeded},
+				{2, "10.0.0.1", nil},
+				{3, "10.0.0.1", ErrLimitExceeded},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, tt.config)
+			l := NewLimiter()
+
+			rejected := 0
+			var slots []*Slot
+			for i, r := range tt.requests {
+				slot, err := l.Acquire(context.Background(), r.userID, r.ip)
+				if !errors.Is(err, r.err) {
+					t.Fatalf("request %d: got error %v, want %v", i, err, r.err)
+				}
+				if err != nil {
+					rejected++
+					continue
+				}
+				slots = append(slots, slot)
+			}
+
+			if got := l.Usage().Rejected; got != rejected {
+				t.Errorf("got %d rejected, want %d", got, rejected)
+			}
+
+			for _, slot := range slots {
+				slot.Release()
+			}
+			if usage := l.Usage(); len(usage.Users) != 0 || len(usage.IPs) != 0 {
+				t.Errorf("counters left after release: %+v", usage)
+			}
+		})
+	}
+}
+
+func TestReleaseTwice(t *testing.T) {
+	withConfig(t, Config{PerUser: 1})
+	l := NewLimiter()
+
+	first, err := l.Acquire(context.Background(), 1, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Release()
+
+	second, err := l.Acquire(context.Background(), 1, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Releasing the first slot again must not free the second one.
+	first.Release()
+	if _, err := l.Acquire(context.Background(), 1, "10.0.0.1"); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("got error %v, want %v", err, ErrLimitExceeded)
+	}
+	second.Release()
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	withConfig(t, Config{PerUser: 1, QueueTimeout: 5 * time.Second})
+	l := NewLimiter()
+
+	slot, err := l.Acquire(context.Background(), 1, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		waiting, err := l.Acquire(context.Background(), 1, "10.0.0.1")
+		if err == nil {
+			waiting.Release()
+		}
+		done <- err
+	}()
+
+	waitFor(t, func() bool {
+		usage := l.Usage()
+		return len(usage.Users) == 1 && usage.Users[0].Waiting == 1
+	})
+	slot.Release()
+
+	if err := <-done; err != nil {
+		t.Fatalf("waiting request failed: %v", err)
+	}
+	if usage := l.Usage(); len(usage.Users) != 0 || len(usage.IPs) != 0 {
+		t.Errorf("counters left after release: %+v", usage)
+	}
+}
+
+func TestAcquireQueueTimeout(t *testing.T) {
+	withConfig(t, Config{PerIP: 1, QueueTimeout: 20 * time.Millisecond})
+	l := NewLimiter()
+
+	slot, err := l.Acquire(context.Background(), 1, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slot.Release()
+
+	if _, err := l.Acquire(context.Background(), 2, "10.0.0.1"); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("got error %v, want %v", err, ErrLimitExceeded)
+	}
+
+	usage := l.Usage()
+	if usage.Rejected != 1 {
+		t.Errorf("got %d rejected, want 1", usage.Rejected)
+	}
+	// The user that timed out has nothing in flight and must be pruned.
+	if len(usage.Users) != 1 || usage.Users[0].UserID != 1 {
+		t.Errorf("got users %+v, want only user 1", usage.Users)
+	}
+}
+
+func TestAcquireContextCanceled(t *testing.T) {
+	withConfig(t, Config{PerUser: 1, QueueTimeout: 5 * time.Second})
+	l := NewLimiter()
+
+	slot, err := l.Acquire(context.Background(), 1, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slot.Release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := l.Acquire(ctx, 1, "10.0.0.2")
+		done <- err
+	}()
+
+	waitFor(t, func() bool {
+		usage := l.Usage()
+		return len(usage.Users) == 1 && usage.Users[0].Waiting == 1
+	})
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	usage := l.Usage()
+	if usage.Rejected != 0 {
+		t.Errorf("a canceled request was counted as rejected")
+	}
+	if len(usage.IPs) != 1 || usage.IPs[0].IP != "10.0.0.1" {
+		t.Errorf("got ips %+v, want only 10.0.0.1", usage.IPs)
+	}
+}
+
+func TestAcquireConcurrent(t *testing.T) {
+	const (
+		users    = 4
+		perUser  = 2
+		requests = 50
+	)
+	withConfig(t, Config{PerUser: perUser, PerIP: 6, QueueTimeout: 5 * time.Second})
+	l := NewLimiter()
+
+	var inFlight [users]atomic.Int32
+	var total atomic.Int32
+	var wg sync.WaitGroup
+	errs := make(chan error, users*requests)
+
+	for user := 0; user < users; user++ {
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func(user int) {
+				defer wg.Done()
+				slot, err := l.Acquire(context.Background(), user, "10.0.0.1")
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer slot.Release()
+
+				if n := inFlight[user].Add(1); n > perUser {
+					errs <- errors.New("per user limit exceeded")
+				}
+				if n := total.Add(1); n > 6 {
+					errs <- errors.New("per ip limit exceeded")
+				}
+				time.Sleep(time.Millisecond)
+				total.Add(-1)
+				inFlight[user].Add(-1)
+			}(user)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if usage := l.Usage(); len(usage.Users) != 0 || len(usage.IPs) != 0 || usage.Rejected != 0 {
+		t.Errorf("got usage %+v after every request finished, want none", usage)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,129 @@
+package waf
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Rule struct {
+	Name string
+	// Inspect returns the value that should be forwarded to the application
+	// and whether the request must be blocked.
+	Inspect func(value string) (string, bool)
+}
+
+type RuleSet struct {
+	Name        string
+	Description string
+	Rules       []Rule
+}
+
+func (rs RuleSet) Inspect(value string) (string, *Rule) {
+	for i := range rs.Rules {
+		rewritten, blocked := rs.Rules[i].Inspect(value)
+		if blocked {
+			return value, &rs.Rules[i]
+		}
+		value = rewritten
+	}
+	return value, nil
+}
+
+var defaultKeywords = []string{"SELECT", "UNION", "pg_sleep"}
+
+func KeywordBlacklist(keywords []string) Rule {
+	return Rule{
+		Name: "keyword-blacklist",
+		Inspect: func(value string) (string, bool) {
+			upper := strings.ToUpper(value)
+			for _, keyword := range keywords {
+				if strings.Contains(upper, strings.ToUpper(keyword)) {
+					return value, true
+				}
+			}
+			return value, false
+		},
+	}
+}
+
+func CaseSensitiveKeywordBlacklist(keywords []string) Rule {
+	return Rule{
+		Name: "case-sensitive-keyword-blacklist",
+		Inspect: func(value string) (string, bool) {
+			for _, keyword := range keywords {
+				if strings.Contains(value, keyword) {
+					return value, true
+				}
+			}
+			return value, false
+		},
+	}
+}
+
+func RejectSpaces() Rule {
+	return Rule{
+		Name: "reject-spaces",
+		Inspect: func(value string) (string, bool) {
+			return value, strings.Contains(value, " ")
+		},
+	}
+}
+
+var commentPattern = regexp.MustCompile(`/\*.*?\*/|--[^\n]*`)
+
+// StripComments removes comments in a single pass, so comments that only
+// appear once the first ones are gone survive it.
+func StripComments() Rule {
+	return Rule{
+		Name: "strip-comments",
+		Inspect: func(value string) (string, bool) {
+			return commentPattern.ReplaceAllString(value, ""), false
+		},
+	}
+}
+
+func MaxLength(limit int) Rule {
+	return Rule{
+		Name: "max-length",
+		Inspect: func(value string) (string, bool) {
+			return value, len(value) > limit
+		},
+	}
+}
+
+var RuleSets = map[string]RuleSet{
+	"off": {
+		Name:        "off",
+		Description: "No filtering.",
+	},
+	"keywords": {
+		Name:        "keywords",
+		Description: "Blocks SELECT, UNION and pg_sleep regardless of case.",
+		Rules:       []Rule{KeywordBlacklist(defaultKeywords)},
+	},
+	"case-sensitive": {
+		Name:        "case-sensitive",
+		Description: "Blocks SELECT, UNION and pg_sleep, but only in this exact case.",
+		Rules:       []Rule{CaseSensitiveKeywordBlacklist(defaultKeywords)},
+	},
+	"no-spaces": {
+		Name:        "no-spaces",
+		Description: "Rejects any value containing a space character.",
+		Rules:       []Rule{RejectSpaces()},
+	},
+	"strip-comments": {
+		Name:        "strip-comments",
+		Description: "Blocks SELECT, UNION and pg_sleep, then strips comments once before forwarding.",
+		Rules:       []Rule{KeywordBlacklist(defaultKeywords), StripComments()},
+	},
+	"length": {
+		Name:        "length",
+		Description: "Rejects values longer than 64 characters.",
+		Rules:       []Rule{MaxLength(64)},
+	},
+	"paranoid": {
+		Name:        "paranoid",
+		Description: "Keyword blacklist, no spaces and a 64 character limit combined.",
+		Rules:       []Rule{KeywordBlacklist(defaultKeywords), RejectSpaces(), MaxLength(64)},
+	},
+}
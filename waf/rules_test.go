@@ -0,0 +1,170 @@
+package waf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		value   string
+		want    string
+		blocked bool
+	}{
+		{"keyword", KeywordBlacklist(defaultKeywords), "1 UNION SELECT 1", "1 UNION SELECT 1", true},
+		{"keyword in any case", KeywordBlacklist(defaultKeywords), "1 uNiOn sElEcT 1", "1 uNiOn sElEcT 1", true},
+		{"keyword inside a word", KeywordBlacklist(defaultKeywords), "reselection", "reselection", true},
+		{"keyword split by comment", KeywordBlacklist(defaultKeywords), "SEL/**/ECT", "SEL/**/ECT", false},
+		{"no keyword", KeywordBlacklist(defaultKeywords), "' OR 1=1--", "' OR 1=1--", false},
+		{"lowercase keyword list", KeywordBlacklist([]string{"pg_sleep"}), "PG_SLEEP(5)", "PG_SLEEP(5)", true},
+
+		{"exact case", CaseSensitiveKeywordBlacklist(defaultKeywords), "1 UNION SELECT 1", "1 UNION SELECT 1", true},
+		{"other case", CaseSensitiveKeywordBlacklist(defaultKeywords), "1 UnIoN sElEcT 1", "1 UnIoN sElEcT 1", false},
+		{"lowercase pg_sleep", CaseSensitiveKeywordBlacklist(defaultKeywords), "pg_sleep(5)", "pg_sleep(5)", true},
+
+		{"space", RejectSpaces(), "' OR 1=1", "' OR 1=1", true},
+		{"comment instead of space", RejectSpaces(), "'/**/OR/**/1=1", "'/**/OR/**/1=1", false},
+		{"tab", RejectSpaces(), "'\tOR\t1=1", "'\tOR\t1=1", false},
+
+		{"block comment", StripComments(), "SEL/**/ECT", "SELECT", false},
+		{"line comment", StripComments(), "x' OR 1=1-- rest", "x' OR 1=1", false},
+		{"line comment ends at newline", StripComments(), "a-- b\nc", "a\nc", false},
+		{"shortest block comment", StripComments(), "a/*1*/b/*2*/c", "abc", false},
+		{"single pass", StripComments(), "SEL/*/**/*/ECT", "SEL*/ECT", false},
+		{"nested comment survives", StripComments(), "/*/**/*/*/", "*/*/", false},
+		{"unterminated block comment", StripComments(), "a/*b", "a/*b", false},
+
+		{"at limit", MaxLength(4), "abcd", "abcd", false},
+		{"over limit", MaxLength(4), "abcde", "abcde", true},
+		{"counts bytes", MaxLength(4), "ééé", "ééé", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, blocked := tt.rule.Inspect(tt.value)
+			if got != tt.want || blocked != tt.blocked {
+				t.Errorf("%s.Inspect(%q) = %q, %t, want %q, %t", tt.rule.Name, tt.value, got, blocked, tt.want, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestRuleSetInspect(t *testing.T) {
+	tests := []struct {
+		ruleSet string
+		value   string
+		want    string
+		rule    string
+	}{
+		{"off", "1 UNION SELECT flag FROM flags", "1 UNION SELECT flag FROM flags", ""},
+		{"keywords", "1 union select 1", "1 union select 1", "keyword-blacklist"},
+		{"case-sensitive", "1 union select 1", "1 union select 1", ""},
+		{"no-spaces", "'/**/OR/**/1=1", "'/**/OR/**/1=1", ""},
+
+		// Keywords are checked before comments are stripped, so a comment
+		// inside a keyword gets it past the blacklist.
+		{"strip-comments", "1 UN/**/ION SEL/**/ECT 1", "1 UNION SELECT 1", ""},
+		{"strip-comments", "1 UNION/**/SELECT 1", "1 UNION/**/SELECT 1", "keyword-blacklist"},
+
+		{"length", strings.Repeat("a", 64), strings.Repeat("a", 64), ""},
+		{"length", strings.Repeat("a", 65), strings.Repeat("a", 65), "max-length"},
+
+		// paranoid reports the first rule that matched.
+		{"paranoid", "1 UNION SELECT 1", "1 UNION SELECT 1", "keyword-blacklist"},
+		{"paranoid", "' OR 1=1", "' OR 1=1", "reject-spaces"},
+		{"paranoid", "'/**/OR/**/" + strings.Repeat("1", 64), "'/**/OR/**/" + strings.Repeat("1", 64), "max-length"},
+		{"paranoid", "'/**/OR/**/1=1", "'/**/OR/**/1=1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ruleSet, func(t *testing.T) {
+			ruleSet, ok := RuleSets[tt.ruleSet]
+			if !ok {
+				t.Fatalf("no rule set %q", tt.ruleSet)
+			}
+
+			got, rule := ruleSet.Inspect(tt.value)
+			if got != tt.want {
+				t.Errorf("Inspect(%q) forwarded %q, want %q", tt.value, got, tt.want)
+			}
+			name := ""
+			if rule != nil {
+				name = rule.Name
+			}
+			if name != tt.rule {
+				t.Errorf("Inspect(%q) matched rule %q, want %q", tt.value, name, tt.rule)
+			}
+		})
+	}
+}
+
+// Every rule sees the value as rewritten by the rules before it.
+func TestRuleSetInspectChainsRewrites(t *testing.T) {
+	ruleSet := RuleSet{Rules: []Rule{StripComments(), MaxLength(3)}}
+
+	got, rule := ruleSet.Inspect("a/**/bc")
+	if rule != nil {
+		t.Fatalf("got rule %q, want none", rule.Name)
+	}
+	if got != "abc" {
+		t.Errorf("got %q, want %q", got, "abc")
+	}
+
+	got, rule = ruleSet.Inspect("a/**/bcd")
+	if rule == nil || rule.Name != "max-length" {
+		t.Fatalf("got rule %v, want max-length", rule)
+	}
+	if got != "abcd" {
+		t.Errorf("got %q, want the value the blocking rule saw", got)
+	}
+}
+
+func TestRuleSetNames(t *testing.T) {
+	for key, ruleSet := range RuleSets {
+		if ruleSet.Name != key {
+			t.Errorf("rule set %q is named %q", key, ruleSet.Name)
+		}
+	}
+
+	names := RuleSetNames()
+	if len(names) != len(RuleSets) {
+		t.Fatalf("got %d names, want %d", len(names), len(RuleSets))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("names are not sorted: %v", names)
+		}
+	}
+}
+
+func TestInitializeRuleSet(t *testing.T) {
+	t.Cleanup(func() { activeRuleSet = RuleSets["off"] })
+
+	tests := []struct {
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{"", "off", false},
+		{"keywords", "keywords", false},
+		{"  Paranoid ", "paranoid", false},
+		{"bogus", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			activeRuleSet = RuleSets["off"]
+			t.Setenv("WAF_RULESET", tt.env)
+
+			err := InitializeRuleSet()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InitializeRuleSet() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && ActiveRuleSet().Name != tt.want {
+				t.Errorf("got rule set %q, want %q", ActiveRuleSet().Name, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package waf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+var activeRuleSet = RuleSets["off"]
+
+func InitializeRuleSet() error {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("WAF_RULESET")))
+	if name == "" {
+		name = "off"
+	}
+
+	ruleSet, ok := RuleSets[name]
+	if !ok {
+		return fmt.Errorf("WAF_RULESET must be one of %s, got %q", strings.Join(RuleSetNames(), ", "), name)
+	}
+	activeRuleSet = ruleSet
+	return nil
+}
+
+func ActiveRuleSet() RuleSet {
+	return activeRuleSet
+}
+
+func RuleSetNames() []string {
+	names := make([]string, 0, len(RuleSets))
+	for name := range RuleSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}